@@ -0,0 +1,197 @@
+package bus
+
+import (
+	"encoding/json"
+
+	"github.com/ledgerhq/satstack/types"
+	"github.com/ledgerhq/satstack/utils"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcutil"
+	log "github.com/sirupsen/logrus"
+)
+
+// verboseBlock is the subset of the response of getblock with verbosity 2,
+// required to compute the fee of each transaction.
+type verboseBlock struct {
+	Hash   string           `json:"hash"`
+	Height int64            `json:"height"`
+	Tx     []verboseBlockTx `json:"tx"`
+}
+
+// verboseBlockTx is a transaction of a verboseBlock.
+type verboseBlockTx struct {
+	TxID string   `json:"txid"`
+	Fee  *float64 `json:"fee"`
+	Vin  []struct {
+		Coinbase string `json:"coinbase"`
+		TxID     string `json:"txid"`
+		Vout     uint32 `json:"vout"`
+	} `json:"vin"`
+	Vout []struct {
+		Value float64 `json:"value"`
+	} `json:"vout"`
+}
+
+// GetBlockFees returns the transactions of the block with the given hash,
+// each annotated with the fee it paid. The coinbase transaction pays no fee.
+//
+// The getblockstats RPC only reports aggregated fees, and confirmed
+// transactions are evicted from the mempool, so the fees are resolved as
+// follows:
+//
+//  1. Recent versions of Bitcoin Core report the fee of each transaction
+//     in getblock with verbosity 2, using the undo data of the block. This
+//     is a single RPC call, and is the preferred path.
+//  2. If the fee is missing, for ex: on older nodes, or blocks whose undo
+//     data was pruned, the previous outputs are fetched with a single batch
+//     of getrawtransaction calls. This is expensive for large blocks, and
+//     requires a transaction index.
+//
+// If none of the above is possible, for ex: if a previous output could not
+// be resolved, the fee of the transaction is nil.
+func (b *Bus) GetBlockFees(hash *chainhash.Hash) (*types.BlockFees, error) {
+	var raw json.RawMessage
+	err := b.guard(chainRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("getblock", []json.RawMessage{
+			json.RawMessage(`"` + hash.String() + `"`),
+			json.RawMessage("2"),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var block verboseBlock
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, err
+	}
+
+	result := types.BlockFees{
+		Hash:         block.Hash,
+		Height:       block.Height,
+		Transactions: make([]types.TransactionFee, len(block.Tx)),
+	}
+
+	var missing []int
+	for idx, tx := range block.Tx {
+		result.Transactions[idx].Hash = tx.TxID
+
+		switch {
+		case idx == 0:
+			// By consensus, the coinbase is the first transaction
+			// of the block.
+			fee := btcutil.Amount(0)
+			result.Transactions[idx].Fee = &fee
+		case tx.Fee != nil:
+			fee := utils.ParseSmallestUnit(*tx.Fee, b.Decimals)
+			result.Transactions[idx].Fee = &fee
+		default:
+			missing = append(missing, idx)
+		}
+	}
+
+	if len(missing) == 0 {
+		return &result, nil
+	}
+
+	if !b.TxIndex {
+		log.WithFields(log.Fields{
+			"block":   block.Hash,
+			"missing": len(missing),
+		}).Warn("Unable to compute fees without transaction index")
+		return &result, nil
+	}
+
+	prevouts, err := b.getPrevoutValues(block, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range missing {
+		result.Transactions[idx].Fee = prevoutFee(block.Tx[idx], prevouts, b.Decimals)
+
+		if result.Transactions[idx].Fee == nil {
+			log.WithFields(log.Fields{
+				"block": block.Hash,
+				"hash":  block.Tx[idx].TxID,
+			}).Warn("Unable to resolve previous outputs of transaction")
+		}
+	}
+
+	return &result, nil
+}
+
+// prevoutFee computes the fee of a transaction from the values of the
+// outputs it spends. It returns nil if any of them is unknown, since the fee
+// cannot be computed from a partial sum.
+func prevoutFee(
+	tx verboseBlockTx, prevouts map[types.OutputIdentifier]btcutil.Amount, decimals int,
+) *btcutil.Amount {
+	var fee btcutil.Amount
+	for _, vin := range tx.Vin {
+		value, ok := prevouts[types.OutputIdentifier{Hash: vin.TxID, Index: vin.Vout}]
+		if !ok {
+			return nil
+		}
+
+		fee += value
+	}
+
+	for _, vout := range tx.Vout {
+		fee -= utils.ParseSmallestUnit(vout.Value, decimals)
+	}
+
+	return &fee
+}
+
+// getPrevoutValues returns the values of the outputs spent by the
+// transactions of the block at the given indexes, using a single batch of
+// getrawtransaction calls.
+func (b *Bus) getPrevoutValues(block verboseBlock, indexes []int) (map[types.OutputIdentifier]btcutil.Amount, error) {
+	client, err := b.ClientFactory()
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Shutdown()
+
+	batch := client.Batch()
+
+	futures := make(map[string]rpcclient.FutureGetRawTransactionResult)
+	for _, idx := range indexes {
+		for _, vin := range block.Tx[idx].Vin {
+			if _, ok := futures[vin.TxID]; ok {
+				continue
+			}
+
+			txHash, err := utils.ParseChainHash(vin.TxID)
+			if err != nil {
+				return nil, err
+			}
+
+			futures[vin.TxID] = batch.GetRawTransactionAsync(txHash)
+		}
+	}
+
+	if err := b.guard(chainRPC, batch.Send); err != nil {
+		return nil, err
+	}
+
+	result := make(map[types.OutputIdentifier]btcutil.Amount)
+	for txID, future := range futures {
+		tx, err := future.Receive()
+		if err != nil {
+			return nil, err
+		}
+
+		for index, txOut := range tx.MsgTx().TxOut {
+			outpoint := types.OutputIdentifier{Hash: txID, Index: uint32(index)}
+			result[outpoint] = btcutil.Amount(txOut.Value)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package bus
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcutil"
+)
+
+func TestPrevoutFee(t *testing.T) {
+	const spendingTx = `{
+		"txid": "b1fea52486ce0c62bb442b530a3f0132b826c74e473d1f2c220bfa78111c5082",
+		"vin": [
+			{"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", "vout": 0},
+			{"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", "vout": 1}
+		],
+		"vout": [{"value": 0.0009}, {"value": 0.0001}]
+	}`
+
+	var tx verboseBlockTx
+	if err := json.Unmarshal([]byte(spendingTx), &tx); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	prevoutHash := "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	first := types.OutputIdentifier{Hash: prevoutHash, Index: 0}
+	second := types.OutputIdentifier{Hash: prevoutHash, Index: 1}
+
+	tests := []struct {
+		name     string
+		prevouts map[types.OutputIdentifier]btcutil.Amount
+		want     *btcutil.Amount
+	}{
+		{
+			name:     "all previous outputs known",
+			prevouts: map[types.OutputIdentifier]btcutil.Amount{first: 60000, second: 50000},
+			want:     amount(10000),
+		},
+		{
+			name:     "unresolved previous output",
+			prevouts: map[types.OutputIdentifier]btcutil.Amount{first: 60000},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prevoutFee(tx, tt.prevouts, 8)
+
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Errorf("prevoutFee() = %v, want %v", got, tt.want)
+			case *got != *tt.want:
+				t.Errorf("prevoutFee() = %d, want %d", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func amount(value btcutil.Amount) *btcutil.Amount {
+	return &value
+}
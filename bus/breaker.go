@@ -0,0 +1,174 @@
+package bus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+const (
+	// breakerThreshold indicates the number of consecutive failed RPC calls
+	// of a method class, after which the circuit breaker opens.
+	breakerThreshold = 5
+
+	// breakerCooldown indicates the duration for which an open circuit
+	// breaker fast-fails requests, before probing the node again.
+	breakerCooldown = 30 * time.Second
+)
+
+// rpcClass groups RPC methods that share the same load profile on the node.
+// Each class has its own circuit breaker.
+type rpcClass string
+
+const (
+	chainRPC   rpcClass = "chain"   // block, header and raw transaction lookups
+	walletRPC  rpcClass = "wallet"  // wallet transaction lookups and listings
+	mempoolRPC rpcClass = "mempool" // mempool lookups
+)
+
+// breakerState is the state of a circuitBreaker.
+type breakerState int
+
+const (
+	// breakerClosed lets every request through.
+	breakerClosed breakerState = iota
+
+	// breakerOpen fast-fails every request with ErrCircuitOpen.
+	breakerOpen
+
+	// breakerHalfOpen lets a single probe request through, and fast-fails
+	// the others until the probe completes.
+	breakerHalfOpen
+)
+
+// circuitBreaker sheds load from the node when it is overloaded.
+//
+// After threshold consecutive failures, the breaker opens and requests fail
+// immediately with ErrCircuitOpen. Once the cooldown has elapsed, a single
+// probe request is allowed (half-open state). The breaker closes if the probe
+// succeeds, and opens again otherwise.
+//
+// Each time the breaker opens, a new generation starts. The outcome of a
+// request that was allowed in an earlier generation is ignored, so that a
+// slow request started before the breaker opened can neither close it, nor
+// count as a failed probe.
+//
+// It is safe for concurrent use.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time // overridable clock
+
+	state      breakerState
+	failures   int
+	openedAt   time.Time
+	probing    bool
+	generation uint64
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a request may be performed, along with the current
+// generation of the breaker. Every allowed request must be followed by a
+// call to done, with the returned generation.
+func (cb *circuitBreaker) allow() (uint64, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.cooldown {
+			return cb.generation, false
+		}
+
+		cb.state = breakerHalfOpen
+		cb.probing = true
+		return cb.generation, true
+
+	case breakerHalfOpen:
+		if cb.probing {
+			return cb.generation, false
+		}
+
+		cb.probing = true
+		return cb.generation, true
+
+	default:
+		return cb.generation, true
+	}
+}
+
+// done records the outcome of an allowed request, started in the given
+// generation of the breaker.
+func (cb *circuitBreaker) done(generation uint64, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.generation {
+		// The breaker opened while the request was in flight.
+		return
+	}
+
+	if success {
+		cb.state = breakerClosed
+		cb.failures = 0
+		cb.probing = false
+		return
+	}
+
+	cb.failures++
+
+	if cb.state == breakerHalfOpen || cb.failures >= cb.threshold {
+		cb.state = breakerOpen
+		cb.openedAt = cb.now()
+		cb.probing = false
+		cb.generation++
+	}
+}
+
+// newBreakers returns a circuit breaker for each RPC method class.
+func newBreakers() map[rpcClass]*circuitBreaker {
+	return map[rpcClass]*circuitBreaker{
+		chainRPC:   newCircuitBreaker(breakerThreshold, breakerCooldown),
+		walletRPC:  newCircuitBreaker(breakerThreshold, breakerCooldown),
+		mempoolRPC: newCircuitBreaker(breakerThreshold, breakerCooldown),
+	}
+}
+
+// guard performs an RPC call through the circuit breaker of its class.
+//
+// Only transport-level errors (timeouts, refused connections, etc) count as
+// failures. An error returned by the node in a JSON-RPC response, like an
+// unknown transaction, means that the node is responsive.
+//
+// Every RPC call of the Bus on behalf of a client must be guarded, including
+// JSON-RPC batches, as well as the periodic calls of the worker. The calls
+// performed at startup and during the initial sync of the worker are not,
+// since an open circuit would abort SatStack.
+func (b *Bus) guard(class rpcClass, call func() error) error {
+	cb, ok := b.breakers[class]
+	if !ok {
+		return call()
+	}
+
+	generation, ok := cb.allow()
+	if !ok {
+		return ErrCircuitOpen
+	}
+
+	err := call()
+
+	_, isRPCError := err.(*btcjson.RPCError)
+	cb.done(generation, err == nil || isRPCError)
+
+	return err
+}
@@ -0,0 +1,232 @@
+package bus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// testClock is a manually advanced clock for circuit breakers.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestBreaker(clock *testClock) *circuitBreaker {
+	cb := newCircuitBreaker(3, time.Minute)
+	cb.now = clock.Now
+	return cb
+}
+
+// fail records n failed requests on the breaker.
+func fail(t *testing.T, cb *circuitBreaker, n int) {
+	for i := 0; i < n; i++ {
+		generation, ok := cb.allow()
+		if !ok {
+			t.Fatalf("allow() = false after %d failures, want true", i)
+		}
+
+		cb.done(generation, false)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, cb *circuitBreaker, clock *testClock)
+		want breakerState
+	}{
+		{
+			name: "stays closed below threshold",
+			run: func(t *testing.T, cb *circuitBreaker, clock *testClock) {
+				fail(t, cb, 2)
+			},
+			want: breakerClosed,
+		},
+		{
+			name: "success resets failures",
+			run: func(t *testing.T, cb *circuitBreaker, clock *testClock) {
+				fail(t, cb, 2)
+				generation, _ := cb.allow()
+				cb.done(generation, true)
+				fail(t, cb, 2)
+			},
+			want: breakerClosed,
+		},
+		{
+			name: "opens at threshold",
+			run: func(t *testing.T, cb *circuitBreaker, clock *testClock) {
+				fail(t, cb, 3)
+				if _, ok := cb.allow(); ok {
+					t.Errorf("allow() = true while open, want false")
+				}
+			},
+			want: breakerOpen,
+		},
+		{
+			name: "single probe after cooldown",
+			run: func(t *testing.T, cb *circuitBreaker, clock *testClock) {
+				fail(t, cb, 3)
+				clock.now = clock.now.Add(time.Minute)
+
+				if _, ok := cb.allow(); !ok {
+					t.Errorf("allow() = false after cooldown, want true")
+				}
+
+				if _, ok := cb.allow(); ok {
+					t.Errorf("allow() = true during probe, want false")
+				}
+			},
+			want: breakerHalfOpen,
+		},
+		{
+			name: "successful probe closes",
+			run: func(t *testing.T, cb *circuitBreaker, clock *testClock) {
+				fail(t, cb, 3)
+				clock.now = clock.now.Add(time.Minute)
+				generation, _ := cb.allow()
+				cb.done(generation, true)
+			},
+			want: breakerClosed,
+		},
+		{
+			name: "failed probe opens again",
+			run: func(t *testing.T, cb *circuitBreaker, clock *testClock) {
+				fail(t, cb, 3)
+				clock.now = clock.now.Add(time.Minute)
+				generation, _ := cb.allow()
+				cb.done(generation, false)
+			},
+			want: breakerOpen,
+		},
+		{
+			name: "stale success does not close",
+			run: func(t *testing.T, cb *circuitBreaker, clock *testClock) {
+				stale, _ := cb.allow() // slow request in flight
+				fail(t, cb, 3)
+				cb.done(stale, true)
+			},
+			want: breakerOpen,
+		},
+		{
+			name: "stale failure is not a failed probe",
+			run: func(t *testing.T, cb *circuitBreaker, clock *testClock) {
+				stale, _ := cb.allow() // slow request in flight
+				fail(t, cb, 3)
+				clock.now = clock.now.Add(time.Minute)
+				probe, _ := cb.allow()
+				cb.done(stale, false)
+
+				if cb.state != breakerHalfOpen {
+					t.Errorf("state = %d after stale failure, want half-open", cb.state)
+				}
+
+				cb.done(probe, true)
+			},
+			want: breakerClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{now: time.Unix(1600000000, 0)}
+			cb := newTestBreaker(clock)
+
+			tt.run(t, cb, clock)
+
+			if cb.state != tt.want {
+				t.Errorf("state = %d, want %d", cb.state, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	b := &Bus{breakers: newBreakers()}
+
+	// RPC errors mean that the node is responsive.
+	rpcErr := btcjson.NewRPCError(btcjson.ErrRPCInvalidAddressOrKey, "No such mempool or blockchain transaction")
+	for i := 0; i < breakerThreshold; i++ {
+		if err := b.guard(chainRPC, func() error { return rpcErr }); err != rpcErr {
+			t.Fatalf("guard() error = %v, want %v", err, rpcErr)
+		}
+	}
+
+	transportErr := errors.New("connection refused")
+	for i := 0; i < breakerThreshold; i++ {
+		if err := b.guard(chainRPC, func() error { return transportErr }); err != transportErr {
+			t.Fatalf("guard() error = %v, want %v", err, transportErr)
+		}
+	}
+
+	called := false
+	err := b.guard(chainRPC, func() error {
+		called = true
+		return nil
+	})
+	if err != ErrCircuitOpen || called {
+		t.Errorf("guard() on open circuit error = %v, called = %v, want %v without call",
+			err, called, ErrCircuitOpen)
+	}
+
+	// Other classes have their own breaker.
+	if err := b.guard(walletRPC, func() error { return nil }); err != nil {
+		t.Errorf("guard() on wallet class error = %v, want nil", err)
+	}
+}
+
+func TestGuardBatch(t *testing.T) {
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+
+	blockHash, _ := chainhash.NewHashFromStr("000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f")
+
+	tests := []struct {
+		name  string
+		class rpcClass
+		call  func(b *Bus) error
+	}{
+		{
+			name:  "derivation paths",
+			class: walletRPC,
+			call: func(b *Bus) error {
+				_, err := b.GetDerivationPaths([]string{address})
+				return err
+			},
+		},
+		{
+			name:  "last common block",
+			class: chainRPC,
+			call: func(b *Bus) error {
+				_, _, err := b.FindLastCommonBlock([]*chainhash.Hash{blockHash})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			b := newTestRPCBus(t, func(method string) (interface{}, *btcjson.RPCError) {
+				t.Errorf("unexpected RPC method %s on open circuit", method)
+				return nil, nil
+			}, &calls)
+
+			b.breakers = newBreakers()
+			cb := b.breakers[tt.class]
+			cb.state = breakerOpen
+			cb.openedAt = cb.now()
+
+			if err := tt.call(b); err != ErrCircuitOpen {
+				t.Errorf("error = %v, want %v", err, ErrCircuitOpen)
+			}
+
+			if calls != 0 {
+				t.Errorf("RPC calls = %d, want 0", calls)
+			}
+		})
+	}
+}
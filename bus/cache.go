@@ -1,21 +1,43 @@
 package bus
 
-import "github.com/patrickmn/go-cache"
-
-// NewCache initializes a new cache storage and assigns it to the Cache field
-// of Bus.
-func (b *Bus) NewCache() {
-	// cleanupInterval is set to 0 to avoid spinning up the janitor
-	// goroutine.
-	b.Cache = cache.New(cache.NoExpiration, 0)
+import (
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// TxCache caches the transactions fetched through the Bus, against their
+// hash. It is meant to be scoped to a single request, to avoid wasteful
+// querying of the node for the same transaction, and is safe for concurrent
+// use.
+type TxCache struct {
+	bus   *Bus
+	cache *cache.Cache
 }
 
-// FlushCache clears the Bus cache storage, and sets the value of Bus.Cache to
-// nil.
-func (b *Bus) FlushCache() {
-	if b.Cache != nil {
-		b.Cache.Flush()
+// NewTxCache returns an empty TxCache, fetching transactions through the Bus.
+func (b *Bus) NewTxCache() *TxCache {
+	return &TxCache{
+		bus: b,
+
+		// cleanupInterval is set to 0 to avoid spinning up the janitor
+		// goroutine.
+		cache: cache.New(cache.NoExpiration, 0),
 	}
+}
+
+// GetTransaction is the cached version of Bus.GetTransaction.
+func (c *TxCache) GetTransaction(hash string) (*types.Transaction, error) {
+	return c.bus.getTransaction(hash, true, c.cache)
+}
+
+// GetTransactionWithoutBlock is the cached version of
+// Bus.GetTransactionWithoutBlock.
+func (c *TxCache) GetTransactionWithoutBlock(hash string) (*types.Transaction, error) {
+	return c.bus.getTransaction(hash, false, c.cache)
+}
 
-	b.Cache = nil
+// Delete evicts the transaction with the given hash from the cache.
+func (c *TxCache) Delete(hash string) {
+	c.cache.Delete(hash)
 }
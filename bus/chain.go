@@ -1,23 +1,56 @@
 package bus
 
 import (
+	"encoding/json"
+	"time"
+
+	"github.com/ledgerhq/satstack/protocol"
 	"github.com/ledgerhq/satstack/types"
 	"github.com/ledgerhq/satstack/utils"
 
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
 )
 
 func (b *Bus) GetBestBlockHash() (*chainhash.Hash, error) {
-	return b.mainClient.GetBestBlockHash()
+	var hash *chainhash.Hash
+	err := b.guard(chainRPC, func() (err error) {
+		hash, err = b.mainClient.GetBestBlockHash()
+		return err
+	})
+
+	return hash, err
 }
 
 func (b *Bus) GetBlockHash(height int64) (*chainhash.Hash, error) {
-	return b.mainClient.GetBlockHash(height)
+	var hash *chainhash.Hash
+	err := b.guard(chainRPC, func() (err error) {
+		hash, err = b.mainClient.GetBlockHash(height)
+		return err
+	})
+
+	return hash, err
+}
+
+// GetBlockCount returns the height of the current chain tip.
+func (b *Bus) GetBlockCount() (int64, error) {
+	var count int64
+	err := b.guard(chainRPC, func() (err error) {
+		count, err = b.mainClient.GetBlockCount()
+		return err
+	})
+
+	return count, err
 }
 
 func (b *Bus) GetBlock(hash *chainhash.Hash) (*types.Block, error) {
-	nativeBlock, err := b.mainClient.GetBlockVerbose(hash)
+	var nativeBlock *btcjson.GetBlockVerboseResult
+	err := b.guard(chainRPC, func() (err error) {
+		nativeBlock, err = b.mainClient.GetBlockVerbose(hash)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +70,318 @@ func (b *Bus) GetBlock(hash *chainhash.Hash) (*types.Block, error) {
 	return &block, nil
 }
 
+// GetBlockHeader returns the header of the block with the given hash. Block
+// headers are retained by pruned nodes.
+func (b *Bus) GetBlockHeader(hash *chainhash.Hash) (*btcjson.GetBlockHeaderVerboseResult, error) {
+	var header *btcjson.GetBlockHeaderVerboseResult
+	err := b.guard(chainRPC, func() (err error) {
+		header, err = b.mainClient.GetBlockHeaderVerbose(hash)
+		return err
+	})
+
+	return header, err
+}
+
+// GetRecentBlockTimes returns the header timestamps of the last count blocks
+// of the main chain, plus their parent, in the order of increasing height.
+//
+// The block hashes and headers are fetched with two batches of RPC requests.
+// ErrRangeTooLarge is returned if count exceeds MaxBlockRange.
+func (b *Bus) GetRecentBlockTimes(count int64) ([]int64, error) {
+	if err := b.CheckBlockRange(count); err != nil {
+		return nil, err
+	}
+
+	tipHeight, err := b.GetBlockCount()
+	if err != nil {
+		return nil, err
+	}
+
+	startHeight := tipHeight - count
+	if startHeight < 0 {
+		startHeight = 0
+	}
+
+	client, err := b.ClientFactory()
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Shutdown()
+
+	batch := client.Batch()
+
+	hashFutures := make([]rpcclient.FutureGetBlockHashResult, 0, tipHeight-startHeight+1)
+	for height := startHeight; height <= tipHeight; height++ {
+		hashFutures = append(hashFutures, batch.GetBlockHashAsync(height))
+	}
+
+	if err := b.guard(chainRPC, batch.Send); err != nil {
+		return nil, err
+	}
+
+	headerFutures := make([]rpcclient.FutureGetBlockHeaderVerboseResult, len(hashFutures))
+	for i, future := range hashFutures {
+		hash, err := future.Receive()
+		if err != nil {
+			return nil, err
+		}
+
+		headerFutures[i] = batch.GetBlockHeaderVerboseAsync(hash)
+	}
+
+	if err := b.guard(chainRPC, batch.Send); err != nil {
+		return nil, err
+	}
+
+	times := make([]int64, len(headerFutures))
+	for i, future := range headerFutures {
+		header, err := future.Receive()
+		if err != nil {
+			return nil, err
+		}
+
+		times[i] = header.Time
+	}
+
+	return times, nil
+}
+
+// FindLastCommonBlock returns the first block of the given list that is on
+// the main chain of the node, along with the current tip. The list is
+// expected newest first, like a block locator, so that the result is the
+// most recent block shared by the client and the node.
+//
+// Blocks unknown to the node, and stale blocks, are skipped. The common block
+// is nil if none of the blocks is on the main chain.
+//
+// The block headers are fetched with a single batch of RPC requests.
+func (b *Bus) FindLastCommonBlock(hashes []*chainhash.Hash) (*types.Block, *types.Block, error) {
+	client, err := b.ClientFactory()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer client.Shutdown()
+
+	batch := client.Batch()
+
+	tipFuture := batch.GetBestBlockHashAsync()
+
+	headerFutures := make([]rpcclient.FutureGetBlockHeaderVerboseResult, len(hashes))
+	for i, hash := range hashes {
+		headerFutures[i] = batch.GetBlockHeaderVerboseAsync(hash)
+	}
+
+	if err := b.guard(chainRPC, batch.Send); err != nil {
+		return nil, nil, err
+	}
+
+	tipHash, err := tipFuture.Receive()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var common *types.Block
+	for _, future := range headerFutures {
+		header, err := future.Receive()
+		if rpcErr, ok := err.(*btcjson.RPCError); ok && rpcErr.Code == btcjson.ErrRPCInvalidAddressOrKey {
+			// Block not found
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if common == nil && InMainChain(header) {
+			common = blockFromHeaderResult(header)
+		}
+	}
+
+	tipHeader, err := b.GetBlockHeader(tipHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return common, blockFromHeaderResult(tipHeader), nil
+}
+
+// blockFromHeaderResult converts a block header to a types.Block, without
+// the list of transactions.
+func blockFromHeaderResult(header *btcjson.GetBlockHeaderVerboseResult) *types.Block {
+	return &types.Block{
+		Hash:   header.Hash,
+		Height: int64(header.Height),
+		Time:   utils.ParseUnixTimestamp(header.Time),
+	}
+}
+
+// InMainChain reports whether the block of the given header is an ancestor
+// of the current tip (or the tip itself).
+//
+// Bitcoin Core reports -1 confirmations for the blocks it knows about, that
+// are not on the main chain, for ex: stale blocks after a reorg.
+func InMainChain(header *btcjson.GetBlockHeaderVerboseResult) bool {
+	return header.Confirmations >= 0
+}
+
+// GetBlockDelta returns the outpoints spent and created by the block with the
+// given hash.
+func (b *Bus) GetBlockDelta(hash *chainhash.Hash) (*types.BlockDelta, error) {
+	var msgBlock *wire.MsgBlock
+	err := b.guard(chainRPC, func() (err error) {
+		msgBlock, err = b.mainClient.GetBlock(hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := b.GetBlockHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	spent, created := protocol.BlockOutpoints(msgBlock)
+
+	return &types.BlockDelta{
+		Hash:    header.Hash,
+		Height:  int64(header.Height),
+		Spent:   spent,
+		Created: created,
+	}, nil
+}
+
+// GetValidationState returns the validation state of the node, based on its
+// response to the getblockchaininfo RPC.
+//
+// The btcjson.GetBlockChainInfoResult type lacks the initialblockdownload
+// field, so the RPC response is decoded manually.
+func (b *Bus) GetValidationState() (*ValidationState, error) {
+	var raw json.RawMessage
+	err := b.guard(chainRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("getblockchaininfo", nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Chain                string  `json:"chain"`
+		Blocks               int64   `json:"blocks"`
+		Headers              int64   `json:"headers"`
+		InitialBlockDownload bool    `json:"initialblockdownload"`
+		VerificationProgress float64 `json:"verificationprogress"`
+	}
+
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, err
+	}
+
+	return &ValidationState{
+		Chain:                info.Chain,
+		Blocks:               info.Blocks,
+		Headers:              info.Headers,
+		InitialBlockDownload: info.InitialBlockDownload,
+		VerificationProgress: info.VerificationProgress,
+		AssumeValid:          assumeValidByDefault(info.Chain),
+	}, nil
+}
+
+// assumeValidByDefault reports whether Bitcoin Core enables assumevalid by
+// default on the given chain.
+func assumeValidByDefault(chain string) bool {
+	switch chain {
+	case "main", "test":
+		return true
+	default:
+		return false
+	}
+}
+
 func (b *Bus) GetBlockChainInfo() (*btcjson.GetBlockChainInfoResult, error) {
-	return b.mainClient.GetBlockChainInfo()
+	var info *btcjson.GetBlockChainInfoResult
+	err := b.guard(chainRPC, func() (err error) {
+		info, err = b.mainClient.GetBlockChainInfo()
+		return err
+	})
+
+	return info, err
+}
+
+// GetParentMedianTime returns the median time past of the parent of the block
+// with the given hash, or of the block itself if it is the genesis block.
+//
+// The btcjson.GetBlockHeaderVerboseResult type lacks the mediantime field, so
+// the RPC responses are decoded manually.
+func (b *Bus) GetParentMedianTime(hash string) (int64, error) {
+	header, err := b.getRawBlockHeader(hash)
+	if err != nil {
+		return 0, err
+	}
+
+	if header.PreviousHash == "" {
+		return header.MedianTime, nil
+	}
+
+	parent, err := b.getRawBlockHeader(header.PreviousHash)
+	if err != nil {
+		return 0, err
+	}
+
+	return parent.MedianTime, nil
+}
+
+// rawBlockHeader is the subset of the verbose getblockheader response,
+// required to compute median times.
+type rawBlockHeader struct {
+	MedianTime   int64  `json:"mediantime"`
+	PreviousHash string `json:"previousblockhash"`
+}
+
+func (b *Bus) getRawBlockHeader(hash string) (*rawBlockHeader, error) {
+	var raw json.RawMessage
+	err := b.guard(chainRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("getblockheader", []json.RawMessage{
+			json.RawMessage(`"` + hash + `"`),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var header rawBlockHeader
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+
+	return &header, nil
+}
+
+// IsTipStale reports whether the block at the tip of the best chain is older
+// than Bus.StaleTipThreshold. A stale tip usually means that the node is
+// disconnected from its peers, or stuck.
+func (b *Bus) IsTipStale() (bool, error) {
+	hash, err := b.GetBestBlockHash()
+	if err != nil {
+		return false, err
+	}
+
+	header, err := b.GetBlockHeader(hash)
+	if err != nil {
+		return false, err
+	}
+
+	return isTipStale(time.Unix(header.Time, 0), time.Now(), b.StaleTipThreshold), nil
+}
+
+// isTipStale compares the time of the tip block against the current time.
+//
+// Block timestamps are only loosely ordered, so a tip time in the future is
+// never considered stale.
+func isTipStale(tipTime time.Time, now time.Time, threshold time.Duration) bool {
+	return now.Sub(tipTime) > threshold
 }
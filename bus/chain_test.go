@@ -0,0 +1,31 @@
+package bus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsTipStale(t *testing.T) {
+	const threshold = 90 * time.Minute
+
+	now := time.Unix(1600000000, 0)
+
+	tests := []struct {
+		name    string
+		tipTime time.Time
+		want    bool
+	}{
+		{"old tip", now.Add(-3 * time.Hour), true},
+		{"fresh tip", now.Add(-10 * time.Minute), false},
+		{"exactly at threshold", now.Add(-threshold), false},
+		{"tip in the future", now.Add(2 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTipStale(tt.tipTime, now, threshold); got != tt.want {
+				t.Errorf("isTipStale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package bus
+
+import (
+	"fmt"
+
+	"github.com/ledgerhq/satstack/protocol"
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// GetDerivationPaths returns the HD derivation path of each of the given
+// addresses that belongs to the wallet, indexed by address. Addresses that
+// are not owned by the wallet, or whose derivation path is unknown, are
+// omitted.
+//
+// The addresses are looked up with a single batch of getaddressinfo calls,
+// instead of one call per address. Descriptor wallets report the derivation
+// path of the addresses derived from ranged descriptors.
+func (b *Bus) GetDerivationPaths(addresses []string) (map[string]string, error) {
+	client, err := b.ClientFactory()
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Shutdown()
+
+	batch := client.Batch()
+
+	futures := make(map[string]rpcclient.FutureGetAddressInfoResult)
+	for _, address := range addresses {
+		if _, ok := futures[address]; ok || address == "" {
+			continue
+		}
+
+		futures[address] = batch.GetAddressInfoAsync(address)
+	}
+
+	if len(futures) == 0 {
+		return map[string]string{}, nil
+	}
+
+	if err := b.guard(walletRPC, batch.Send); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	for address, future := range futures {
+		info, err := future.Receive()
+		if err != nil {
+			return nil, fmt.Errorf("%s (%s): %w", ErrAddressInfo, address, err)
+		}
+
+		if !info.IsMine && !info.IsWatchOnly {
+			continue
+		}
+
+		if info.HDKeyPath != nil {
+			result[address] = *info.HDKeyPath
+		}
+	}
+
+	return result, nil
+}
+
+// GetBlockTransactions returns the decoded transactions of the block with the
+// given hash, without resolving their inputs.
+func (b *Bus) GetBlockTransactions(hash *chainhash.Hash) ([]*types.Transaction, error) {
+	var msgBlock *wire.MsgBlock
+	err := b.guard(chainRPC, func() (err error) {
+		msgBlock, err = b.mainClient.GetBlock(hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]*types.Transaction, len(msgBlock.Transactions))
+	for i, msgTx := range msgBlock.Transactions {
+		txs[i] = protocol.DecodeMsgTx(msgTx, b.Params)
+	}
+
+	return txs, nil
+}
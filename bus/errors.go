@@ -1,6 +1,9 @@
 package bus
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrBitcoindUnreachable indicates that an RPC call to the bitcoind node
@@ -59,4 +62,53 @@ var (
 	// ErrAddressInfo indicates that an error was encountered while trying to
 	// fetch address info.
 	ErrAddressInfo = errors.New("failed to get address info")
+
+	// ErrListDescriptors indicates that the descriptors imported in the
+	// wallet could not be listed.
+	ErrListDescriptors = errors.New("failed to list descriptors")
+
+	// ErrTestMempoolAccept indicates that the testmempoolaccept RPC was not
+	// successful. It does not indicate that a transaction was rejected.
+	ErrTestMempoolAccept = errors.New("failed to test mempool acceptance")
+
+	// ErrCircuitOpen indicates that an RPC call was not attempted, because
+	// the node failed to respond to too many consecutive calls of the same
+	// class. The call can be retried after a cooldown.
+	ErrCircuitOpen = errors.New("circuit breaker open: node overloaded")
+
+	// ErrFirstSeenUnknown indicates that a transaction was never observed
+	// in the mempool by SatStack.
+	ErrFirstSeenUnknown = errors.New("transaction first-seen data unknown")
+
+	// ErrTransactionUnconfirmed indicates that an operation expected a
+	// confirmed transaction, but the transaction is not in a block yet.
+	ErrTransactionUnconfirmed = errors.New("transaction is unconfirmed")
+
+	// ErrNoWalletTransactions indicates that the wallet does not have any
+	// transaction yet.
+	ErrNoWalletTransactions = errors.New("no wallet transactions")
+
+	// ErrPinnedTipReorged indicates that the chain tip pinned by a client
+	// is no longer on the main chain, typically after a reorg.
+	ErrPinnedTipReorged = errors.New("pinned tip is not on the main chain")
+
+	// ErrNotInMempool indicates that a transaction is not in the mempool of
+	// the node, either because it is confirmed, or unknown.
+	ErrNotInMempool = errors.New("transaction not in mempool")
+
+	// ErrRangeTooLarge indicates that a range scan was rejected, because it
+	// covers more blocks than the configured maximum.
+	ErrRangeTooLarge = errors.New("block range too large")
 )
+
+// ErrBlockHeightOutOfRange indicates that a block was requested by height,
+// but the height is negative or above the current chain tip.
+type ErrBlockHeightOutOfRange struct {
+	Requested int64 `json:"requested_height"`
+	Tip       int64 `json:"tip_height"`
+}
+
+func (e *ErrBlockHeightOutOfRange) Error() string {
+	return fmt.Sprintf("block height %d out of range, tip is at height %d",
+		e.Requested, e.Tip)
+}
@@ -0,0 +1,132 @@
+package bus
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ledgerhq/satstack/utils"
+)
+
+const (
+	// ibdWindow is the period over which the verification progress of the
+	// node is sampled, to estimate the rate of progress.
+	ibdWindow = 10 * time.Minute
+
+	// minIBDWindow is the minimum period between the first and last samples,
+	// below which the rate of progress is deemed too noisy to estimate.
+	minIBDWindow = 30 * time.Second
+
+	// ETAUnknown is reported as the ETA of the initial block download if
+	// there is not enough data to estimate it.
+	ETAUnknown = "unknown"
+)
+
+// progressSnapshot is a sample of the verification progress of the node.
+type progressSnapshot struct {
+	at       time.Time
+	progress float64
+}
+
+// progressTracker is a thread-safe record of the verification progress of
+// the node over the last ibdWindow.
+type progressTracker struct {
+	mu        sync.Mutex
+	snapshots []progressSnapshot
+}
+
+// record adds a sample of the verification progress, and discards the ones
+// older than ibdWindow.
+func (t *progressTracker) record(at time.Time, progress float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.snapshots = append(t.snapshots, progressSnapshot{at: at, progress: progress})
+
+	start := 0
+	for start < len(t.snapshots) && at.Sub(t.snapshots[start].at) > ibdWindow {
+		start++
+	}
+
+	t.snapshots = t.snapshots[start:]
+}
+
+// remaining estimates the time left until the verification progress reaches
+// 1, assuming it keeps increasing at the rate observed between the first and
+// last samples. It returns nil if the samples are too few, too close in time,
+// or show no progress.
+func (t *progressTracker) remaining() *time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.snapshots) < 2 {
+		return nil
+	}
+
+	first, last := t.snapshots[0], t.snapshots[len(t.snapshots)-1]
+
+	elapsed := last.at.Sub(first.at)
+	if elapsed < minIBDWindow || last.progress <= first.progress {
+		return nil
+	}
+
+	rate := (last.progress - first.progress) / elapsed.Seconds()
+	remaining := time.Duration((1 - last.progress) / rate * float64(time.Second))
+	return &remaining
+}
+
+// GetIBDEstimate returns the verification progress of the node, along with
+// the estimated time remaining until the end of the initial block download.
+//
+// The verification progress is sampled by the worker while waiting for the
+// initial block download to complete, and on each call. The estimate is only
+// a rough indication, since the validation of recent blocks is typically
+// slower than that of the early ones.
+func (b *Bus) GetIBDEstimate() (*IBDEstimate, error) {
+	var raw json.RawMessage
+	err := b.guard(chainRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("getblockchaininfo", nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Blocks               int64   `json:"blocks"`
+		Headers              int64   `json:"headers"`
+		InitialBlockDownload bool    `json:"initialblockdownload"`
+		VerificationProgress float64 `json:"verificationprogress"`
+	}
+
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, err
+	}
+
+	estimate := IBDEstimate{
+		InitialBlockDownload: info.InitialBlockDownload,
+		Blocks:               info.Blocks,
+		Headers:              info.Headers,
+		VerificationProgress: info.VerificationProgress,
+		ETA:                  ETAUnknown,
+	}
+
+	if !info.InitialBlockDownload {
+		var zero int64
+		estimate.ETA = utils.HumanizeDuration(0)
+		estimate.RemainingSeconds = &zero
+		return &estimate, nil
+	}
+
+	now := time.Now()
+	b.ibdProgress.record(now, info.VerificationProgress)
+
+	if remaining := b.ibdProgress.remaining(); remaining != nil {
+		seconds := int64(remaining.Seconds())
+		estimate.ETA = utils.HumanizeDuration(remaining.Round(time.Second))
+		estimate.RemainingSeconds = &seconds
+		estimate.CompletionTime = utils.ParseUnixTimestamp(now.Add(*remaining).Unix())
+	}
+
+	return &estimate, nil
+}
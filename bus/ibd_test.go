@@ -0,0 +1,97 @@
+package bus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+func TestProgressTrackerRemaining(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+
+	type sample struct {
+		offset   time.Duration
+		progress float64
+	}
+
+	tests := []struct {
+		name    string
+		samples []sample
+		want    *time.Duration // nil if the ETA is unknown
+	}{
+		{
+			name:    "two samples",
+			samples: []sample{{0, 0.5}, {time.Minute, 0.75}},
+			want:    durationPtr(time.Minute),
+		},
+		{
+			name:    "single sample",
+			samples: []sample{{0, 0.5}},
+		},
+		{
+			name:    "window under minimum",
+			samples: []sample{{0, 0.5}, {minIBDWindow - time.Second, 0.75}},
+		},
+		{
+			name:    "no progress",
+			samples: []sample{{0, 0.5}, {time.Minute, 0.5}},
+		},
+		{
+			name:    "samples older than window discarded",
+			samples: []sample{{0, 0.5}, {ibdWindow + time.Minute, 0.75}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &progressTracker{}
+			for _, s := range tt.samples {
+				tracker.record(start.Add(s.offset), s.progress)
+			}
+
+			got := tracker.remaining()
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("remaining() = %s, want unknown", *got)
+				}
+				return
+			}
+
+			if got == nil {
+				t.Fatalf("remaining() = unknown, want %s", *tt.want)
+			}
+
+			if diff := *got - *tt.want; diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("remaining() = %s, want %s", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestGetIBDEstimateUnknown(t *testing.T) {
+	var calls int
+	b := newTestRPCBus(t, func(method string) (interface{}, *btcjson.RPCError) {
+		return map[string]interface{}{
+			"blocks":               350000,
+			"headers":              700000,
+			"initialblockdownload": true,
+			"verificationprogress": 0.5,
+		}, nil
+	}, &calls)
+	b.ibdProgress = &progressTracker{}
+
+	// A single sample is not enough to estimate the rate of progress.
+	estimate, err := b.GetIBDEstimate()
+	if err != nil {
+		t.Fatalf("GetIBDEstimate() error = %v", err)
+	}
+
+	if estimate.ETA != ETAUnknown || estimate.RemainingSeconds != nil {
+		t.Errorf("GetIBDEstimate() ETA = %s, want %s", estimate.ETA, ETAUnknown)
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
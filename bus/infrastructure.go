@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
 
@@ -31,6 +32,24 @@ const (
 	// supported by SatStack.
 	minSupportedBitcoindVersion = 200000
 
+	// defaultStaleTipIntervals indicates the number of expected block
+	// intervals after which the chain tip is considered stale. On mainnet,
+	// this amounts to 90 minutes without a new block.
+	defaultStaleTipIntervals = 9
+
+	// defaultMaxBlockRange indicates the maximum number of blocks that a
+	// range scan may cover, i.e., one difficulty adjustment period.
+	defaultMaxBlockRange = 2016
+
+	// firstSeenExpiration indicates the duration for which the first-seen
+	// data of a mempool transaction is retained. It matches the default
+	// mempool expiry of Bitcoin Core (-mempoolexpiry=336).
+	firstSeenExpiration = 336 * time.Hour
+
+	// mempoolPollInterval is the interval at which the first-seen heights
+	// of unconfirmed wallet transactions are recorded by the worker.
+	mempoolPollInterval = 30 * time.Second
+
 	// walletName indicates the name of the wallet created by SatStack in
 	// bitcoind's wallet.
 	walletName = "satstack"
@@ -49,9 +68,15 @@ type Bus struct {
 	TxIndex     bool
 	BlockFilter bool
 	Currency    Currency // Based on Chain value, for interoperability with libcore
+	Decimals    int      // Number of decimal places of Currency
+
+	// Thread-safe record of the block height at which unconfirmed
+	// transactions were first seen in the mempool, indexed by hash.
+	firstSeen *cache.Cache
 
-	// Thread-safe Bus cache, to query results typically by hash
-	Cache *cache.Cache
+	// Samples of the verification progress of the node, to estimate the
+	// time remaining until the end of the initial block download.
+	ibdProgress *progressTracker
 
 	// Config to use for creating new connections on-demand.
 	connCfg *rpcclient.ConnConfig
@@ -66,9 +91,20 @@ type Bus struct {
 	// RPC client reserved for performing RPC-based cleanups.
 	janitorClient *rpcclient.Client
 
+	// Circuit breakers of the mainClient, by RPC method class.
+	breakers map[rpcClass]*circuitBreaker
+
 	// btcd network params
 	Params *chaincfg.Params
 
+	// StaleTipThreshold is the maximum age of the chain tip, beyond which
+	// the node is suspected to be disconnected or stuck.
+	StaleTipThreshold time.Duration
+
+	// MaxBlockRange is the maximum number of blocks that a range scan may
+	// cover. Use CheckBlockRange before scanning a range of blocks.
+	MaxBlockRange int64
+
 	// IsPendingScan is a boolean field to indicate if satstack is currently
 	// waiting for descriptors to be scanned. One such example is when satstack
 	// is "running the numbers".
@@ -144,6 +180,11 @@ func New(host string, user string, pass string, proxy string, noTLS bool) (*Bus,
 		return nil, err
 	}
 
+	decimals, err := DecimalsFromChain(info.Chain)
+	if err != nil {
+		return nil, err
+	}
+
 	isNewWallet, err := loadOrCreateWallet(mainClient)
 	if err != nil {
 		return nil, err
@@ -169,14 +210,20 @@ func New(host string, user string, pass string, proxy string, noTLS bool) (*Bus,
 		mainClient:      mainClient,
 		secondaryClient: secondaryClient,
 		janitorClient:   janitorClient,
+		breakers:        newBreakers(),
 		Pruned:          info.Pruned,
 		Chain:           info.Chain,
 		BlockFilter:     blockFilter,
 		TxIndex:         txIndex,
 		Currency:        currency,
-		Cache:           nil, // Disabled by default
+		Decimals:        decimals,
+		firstSeen:       cache.New(firstSeenExpiration, firstSeenExpiration),
+		ibdProgress:     &progressTracker{},
 		Params:          params,
 		IsPendingScan:   true,
+		StaleTipThreshold: defaultStaleTipIntervals *
+			params.TargetTimePerBlock,
+		MaxBlockRange: defaultMaxBlockRange,
 	}
 
 	return b, nil
@@ -211,6 +258,24 @@ func (b *Bus) Close(ctx context.Context) {
 
 }
 
+// SetStaleTipIntervals overrides the StaleTipThreshold of the Bus, expressed
+// as a number of expected block intervals for the connected chain.
+func (b *Bus) SetStaleTipIntervals(intervals int) {
+	b.StaleTipThreshold = time.Duration(intervals) * b.Params.TargetTimePerBlock
+}
+
+// CheckBlockRange returns ErrRangeTooLarge if a scan over the given number of
+// blocks exceeds MaxBlockRange. Methods scanning a range of blocks must call
+// it before sending any request, to protect the node from enormous ranges.
+func (b *Bus) CheckBlockRange(blocks int64) error {
+	if blocks > b.MaxBlockRange {
+		return fmt.Errorf("%w: %d blocks, maximum is %d",
+			ErrRangeTooLarge, blocks, b.MaxBlockRange)
+	}
+
+	return nil
+}
+
 func (b *Bus) ClientFactory() (*rpcclient.Client, error) {
 	return rpcclient.New(b.connCfg, nil)
 }
@@ -237,6 +302,18 @@ func CurrencyFromChain(chain string) (Currency, error) {
 	}
 }
 
+// DecimalsFromChain returns the number of decimal places of the currency
+// corresponding to a chain (network) value. It is used to convert amounts
+// returned by the node in the main unit, to the smallest unit of the currency.
+func DecimalsFromChain(chain string) (int, error) {
+	switch chain {
+	case "regtest", "test", "main":
+		return utils.BitcoinDecimals, nil
+	default:
+		return 0, ErrUnrecognizedChain
+	}
+}
+
 // ChainParams returns the *chaincfg.Params instance corresponding to the
 // network that the underlying node is connected to.
 //
@@ -0,0 +1,469 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/ledgerhq/satstack/protocol"
+	"github.com/ledgerhq/satstack/types"
+	"github.com/ledgerhq/satstack/utils"
+	"github.com/patrickmn/go-cache"
+)
+
+// GetMempoolEntry returns the mempool data of an unconfirmed transaction. It
+// returns an error if the transaction is not in the mempool of the node.
+//
+// The block height at which the transaction entered the mempool is recorded,
+// and can later be used to compute its confirmation delay.
+func (b *Bus) GetMempoolEntry(hash string) (*btcjson.GetMempoolEntryResult, error) {
+	var entry *btcjson.GetMempoolEntryResult
+	err := b.guard(mempoolRPC, func() (err error) {
+		entry, err = b.mainClient.GetMempoolEntry(hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordFirstSeen(hash, entry.Height)
+
+	return entry, nil
+}
+
+// recordFirstSeen records the block height at which a transaction was first
+// seen in the mempool.
+//
+// The earliest known height is kept, in case the transaction was evicted and
+// re-accepted in the mempool.
+func (b *Bus) recordFirstSeen(hash string, height int64) {
+	// Add fails if the hash is already known, which keeps the earliest
+	// height without racing concurrent callers.
+	_ = b.firstSeen.Add(hash, height, cache.DefaultExpiration)
+}
+
+// RecordWalletMempool records the current block height as the first-seen
+// height of every unconfirmed wallet transaction that was not observed before.
+//
+// It is meant to be called periodically, so that the confirmation delay of
+// wallet transactions is known even if no client queried them while
+// unconfirmed. The recorded height is therefore accurate up to the polling
+// interval. Other mempool transactions are not recorded, as their
+// confirmation delay cannot be queried anyway.
+func (b *Bus) RecordWalletMempool() error {
+	bestBlockHash, err := b.GetBestBlockHash()
+	if err != nil {
+		return err
+	}
+
+	header, err := b.GetBlockHeader(bestBlockHash)
+	if err != nil {
+		return err
+	}
+
+	// Listing since the tip yields only the transactions that are not
+	// confirmed yet.
+	var txs *btcjson.ListSinceBlockResult
+	err = b.guard(walletRPC, func() (err error) {
+		txs, err = b.mainClient.ListSinceBlockMinConfWatchOnly(bestBlockHash, 1, true)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, tx := range txs.Transactions {
+		if tx.Confirmations != 0 {
+			// Conflicted, or confirmed after the tip was fetched.
+			continue
+		}
+
+		b.recordFirstSeen(tx.TxID, int64(header.Height))
+	}
+
+	return nil
+}
+
+// ConfirmationDelay returns the number of blocks it took for a confirmed
+// transaction to be mined, since it was first seen in the mempool.
+//
+// It requires the transaction to have been observed in the mempool, either
+// by GetMempoolEntry, SendTransaction or RecordWalletMempool, and to be a
+// wallet transaction. A delay of 1 means that the transaction was mined in
+// the very next block.
+func (b *Bus) ConfirmationDelay(hash *chainhash.Hash) (int64, error) {
+	firstSeenHeight, found := b.firstSeen.Get(hash.String())
+	if !found {
+		return 0, ErrFirstSeenUnknown
+	}
+
+	var tx *btcjson.GetTransactionResult
+	err := b.guard(walletRPC, func() (err error) {
+		tx, err = b.mainClient.GetTransactionWatchOnly(hash, true)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if tx.BlockHash == "" {
+		return 0, ErrTransactionUnconfirmed
+	}
+
+	blockHash, err := chainhash.NewHashFromStr(tx.BlockHash)
+	if err != nil {
+		return 0, fmt.Errorf("%s (%s): %w", ErrMalformedChainHash, tx.BlockHash, err)
+	}
+
+	header, err := b.GetBlockHeader(blockHash)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(header.Height) - firstSeenHeight.(int64), nil
+}
+
+// MempoolEntryFee returns the base fee of a mempool entry, in the smallest
+// unit of the currency.
+//
+// Newer versions of Bitcoin Core only report the fee in the fees object,
+// while the top-level fee field is deprecated.
+func (b *Bus) MempoolEntryFee(entry *btcjson.GetMempoolEntryResult) btcutil.Amount {
+	if entry.Fees.Base > 0 {
+		return utils.ParseSmallestUnit(entry.Fees.Base, b.Decimals)
+	}
+
+	return utils.ParseSmallestUnit(entry.Fee, b.Decimals)
+}
+
+// GetReplaceability computes the BIP-0125 replaceability of a mempool
+// transaction.
+//
+// A transaction is replaceable if it signals replaceability itself, or if any
+// of its unconfirmed ancestors does (inherited replaceability). Ancestors are
+// walked using the depends field of the mempool entries, so only the
+// in-mempool ancestors are visited.
+//
+// Confirmed transactions and transactions not in the mempool are reported as
+// not replaceable.
+func (b *Bus) GetReplaceability(hash *chainhash.Hash) (*types.Replaceability, error) {
+	result := &types.Replaceability{}
+
+	entry, err := b.GetMempoolEntry(hash.String())
+	if isNotInMempool(err) {
+		// Not in the mempool, nothing to replace.
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := b.getRawTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Signaling = protocol.SignalsReplaceability(tx.MsgTx())
+
+	visited := map[string]bool{hash.String(): true}
+	queue := entry.Depends
+
+	for len(queue) > 0 && !result.Inherited {
+		parent := queue[0]
+		queue = queue[1:]
+
+		if visited[parent] {
+			continue
+		}
+		visited[parent] = true
+
+		parentHash, err := chainhash.NewHashFromStr(parent)
+		if err != nil {
+			return nil, fmt.Errorf("%s (%s): %w", ErrMalformedChainHash, parent, err)
+		}
+
+		// The raw transaction of a mempool transaction is always available,
+		// even if the node does not have a transaction index.
+		parentTx, err := b.getRawTransaction(parentHash)
+		if err != nil {
+			return nil, err
+		}
+
+		if protocol.SignalsReplaceability(parentTx.MsgTx()) {
+			result.Inherited = true
+			break
+		}
+
+		parentEntry, err := b.GetMempoolEntry(parent)
+		if isNotInMempool(err) {
+			// The parent got confirmed in the meantime.
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		queue = append(queue, parentEntry.Depends...)
+	}
+
+	result.Replaceable = result.Signaling || result.Inherited
+	return result, nil
+}
+
+// isNotInMempool checks if an error returned by a mempool lookup means that
+// the transaction is not in the mempool, as opposed to a failure to query
+// the node.
+func isNotInMempool(err error) bool {
+	if errors.Is(err, ErrNotInMempool) {
+		return true
+	}
+
+	rpcErr, ok := err.(*btcjson.RPCError)
+	return ok && rpcErr.Code == btcjson.ErrRPCInvalidAddressOrKey
+}
+
+// MempoolInfo models the data returned by the getmempoolinfo RPC.
+//
+// Fee rates are expressed in BTC/kvB.
+type MempoolInfo struct {
+	Size          int64   `json:"size"`
+	Bytes         int64   `json:"bytes"`
+	Usage         int64   `json:"usage"`
+	MaxMempool    int64   `json:"maxmempool"`
+	MempoolMinFee float64 `json:"mempoolminfee"`
+	MinRelayTxFee float64 `json:"minrelaytxfee"`
+
+	// Policy flags reported by recent versions of Bitcoin Core only.
+	FullRBF            *bool  `json:"fullrbf"`
+	PermitBareMultisig *bool  `json:"permitbaremultisig"`
+	MaxDataCarrierSize *int64 `json:"maxdatacarriersize"`
+}
+
+// GetMempoolInfo returns the state of the mempool of the node.
+//
+// The btcjson.GetMempoolInfoResult type lacks the fee-related fields, so the
+// RPC response is decoded manually.
+func (b *Bus) GetMempoolInfo() (*MempoolInfo, error) {
+	var raw json.RawMessage
+	err := b.guard(mempoolRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("getmempoolinfo", nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var info MempoolInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// EvictionFeeRate returns the minimum fee rate, in satoshis per kvB, that a
+// transaction must pay to enter the mempool.
+//
+// When the mempool is full, Bitcoin Core raises mempoolminfee above the
+// minimum relay fee, and evicts the transactions paying less than that. The
+// returned boolean indicates whether it is the case.
+//
+// This is unrelated to the fee rate required to be mined in the next block.
+func (b *Bus) EvictionFeeRate() (btcutil.Amount, bool, error) {
+	info, err := b.GetMempoolInfo()
+	if err != nil {
+		return 0, false, err
+	}
+
+	mempoolMinFee := utils.ParseSmallestUnit(info.MempoolMinFee, b.Decimals)
+	minRelayTxFee := utils.ParseSmallestUnit(info.MinRelayTxFee, b.Decimals)
+
+	if mempoolMinFee > minRelayTxFee {
+		return mempoolMinFee, true, nil
+	}
+
+	return minRelayTxFee, false, nil
+}
+
+// GetRelayPolicy returns the relay policy of the node, based on its response
+// to the getnetworkinfo and getmempoolinfo RPCs.
+func (b *Bus) GetRelayPolicy() (*RelayPolicy, error) {
+	var networkInfo *btcjson.GetNetworkInfoResult
+	err := b.guard(mempoolRPC, func() (err error) {
+		networkInfo, err = b.mainClient.GetNetworkInfo()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mempoolInfo, err := b.GetMempoolInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RelayPolicy{
+		MinRelayFee:         utils.ParseSmallestUnit(mempoolInfo.MinRelayTxFee, b.Decimals),
+		IncrementalRelayFee: utils.ParseSmallestUnit(networkInfo.IncrementalFee, b.Decimals),
+		MempoolMinFee:       utils.ParseSmallestUnit(mempoolInfo.MempoolMinFee, b.Decimals),
+		LocalRelay:          networkInfo.LocalRelay,
+		RequireStandard:     requireStandardByDefault(b.Chain),
+		FullRBF:             mempoolInfo.FullRBF,
+		PermitBareMultisig:  mempoolInfo.PermitBareMultisig,
+		MaxDataCarrierSize:  mempoolInfo.MaxDataCarrierSize,
+	}, nil
+}
+
+// requireStandardByDefault reports whether Bitcoin Core rejects non-standard
+// transactions by default on the given chain.
+func requireStandardByDefault(chain string) bool {
+	return chain != "test"
+}
+
+// rawMempoolEntry is the subset of an entry of the verbose getrawmempool
+// and getmempooldescendants responses, required to compute its fee rate.
+//
+// Older versions of Bitcoin Core report the modified fee and the size at the
+// top level, instead of the fees object and the vsize.
+type rawMempoolEntry struct {
+	VSize       int64   `json:"vsize"`
+	Size        int64   `json:"size"`
+	Fee         float64 `json:"fee"`
+	ModifiedFee float64 `json:"modifiedfee"`
+	Fees        *struct {
+		Base     float64 `json:"base"`
+		Modified float64 `json:"modified"`
+	} `json:"fees"`
+}
+
+// GetMempoolRank returns the rank of an unconfirmed transaction among all the
+// transactions of the mempool, sorted by decreasing fee rate.
+//
+// The fee rate of a transaction is its modified fee (including the deltas set
+// with prioritisetransaction) per vbyte. It does not account for CPFP. Ties
+// are broken by txid, so that the rank is deterministic.
+//
+// Instead of sorting the mempool, the rank is computed in a single pass, by
+// counting the transactions ahead of the given one.
+func (b *Bus) GetMempoolRank(hash *chainhash.Hash) (*types.MempoolRank, error) {
+	var raw json.RawMessage
+	err := b.guard(mempoolRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("getrawmempool", []json.RawMessage{
+			json.RawMessage("true"),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var mempool map[string]rawMempoolEntry
+	if err := json.Unmarshal(raw, &mempool); err != nil {
+		return nil, err
+	}
+
+	txID := hash.String()
+	target, ok := mempool[txID]
+	if !ok {
+		return nil, ErrNotInMempool
+	}
+
+	targetFee, targetSize := b.mempoolFeeAndSize(target)
+
+	rank := 1
+	for id, entry := range mempool {
+		if id == txID {
+			continue
+		}
+
+		fee, size := b.mempoolFeeAndSize(entry)
+
+		// Compare fee/size with targetFee/targetSize, without the loss
+		// of precision of a division.
+		lhs, rhs := int64(fee)*targetSize, int64(targetFee)*size
+		if lhs > rhs || (lhs == rhs && id < txID) {
+			rank++
+		}
+	}
+
+	return &types.MempoolRank{
+		Rank:  rank,
+		Total: len(mempool),
+	}, nil
+}
+
+// mempoolFeeAndSize returns the modified fee and the virtual size of a
+// mempool entry.
+func (b *Bus) mempoolFeeAndSize(entry rawMempoolEntry) (btcutil.Amount, int64) {
+	fee := entry.ModifiedFee
+	if entry.Fees != nil {
+		fee = entry.Fees.Modified
+	}
+
+	size := entry.VSize
+	if size == 0 {
+		size = entry.Size
+	}
+
+	return utils.ParseSmallestUnit(fee, b.Decimals), size
+}
+
+// GetMempoolDescendants returns the unconfirmed descendants of a mempool
+// transaction, i.e., the transactions spending its outputs, recursively,
+// along with their aggregate virtual size and fee.
+//
+// Replacing the transaction, for ex: to bump its fee, evicts all of them.
+func (b *Bus) GetMempoolDescendants(hash *chainhash.Hash) (*types.MempoolDescendants, error) {
+	var raw json.RawMessage
+	err := b.guard(mempoolRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("getmempooldescendants", []json.RawMessage{
+			json.RawMessage(`"` + hash.String() + `"`),
+			json.RawMessage("true"),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]rawMempoolEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+
+	result := types.MempoolDescendants{
+		Descendants: make([]types.MempoolDescendant, 0, len(entries)),
+	}
+
+	for txID, entry := range entries {
+		_, vsize := b.mempoolFeeAndSize(entry)
+
+		fee := entry.Fee
+		if entry.Fees != nil {
+			fee = entry.Fees.Base
+		}
+
+		descendant := types.MempoolDescendant{
+			Hash:  txID,
+			VSize: vsize,
+			Fee:   utils.ParseSmallestUnit(fee, b.Decimals),
+		}
+
+		result.Descendants = append(result.Descendants, descendant)
+		result.VSize += descendant.VSize
+		result.Fee += descendant.Fee
+	}
+
+	// Sort by txid, since the RPC returns a JSON object.
+	sort.Slice(result.Descendants, func(i, j int) bool {
+		return result.Descendants[i].Hash < result.Descendants[j].Hash
+	})
+
+	return &result, nil
+}
@@ -0,0 +1,107 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/patrickmn/go-cache"
+)
+
+func TestRecordFirstSeen(t *testing.T) {
+	b := &Bus{firstSeen: cache.New(firstSeenExpiration, firstSeenExpiration)}
+
+	const hash = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+	b.recordFirstSeen(hash, 100)
+	b.recordFirstSeen(hash, 105) // re-accepted after eviction
+
+	height, found := b.firstSeen.Get(hash)
+	if !found {
+		t.Fatalf("first-seen height of %s not recorded", hash)
+	}
+
+	if height.(int64) != 100 {
+		t.Errorf("first-seen height = %d, want 100", height)
+	}
+}
+
+func TestIsNotInMempool(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no error", nil, false},
+		{"not in mempool", ErrNotInMempool, true},
+		{"wrapped not in mempool", fmt.Errorf("%w: %s", ErrNotInMempool, "txid"), true},
+		{
+			name: "unknown transaction",
+			err:  btcjson.NewRPCError(btcjson.ErrRPCInvalidAddressOrKey, "Transaction not in mempool"),
+			want: true,
+		},
+		{
+			name: "other RPC error",
+			err:  btcjson.NewRPCError(btcjson.ErrRPCInWarmup, "Loading block index..."),
+			want: false,
+		},
+		{"transport error", errors.New("connection refused"), false},
+		{"open circuit", ErrCircuitOpen, false},
+	}
+
+	for _, tt := range tests {
+		if got := isNotInMempool(tt.err); got != tt.want {
+			t.Errorf("isNotInMempool(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecordWalletMempool(t *testing.T) {
+	const (
+		tipHash     = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+		pending     = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+		conflicted  = "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098"
+		lateConfirm = "9b0fc92260312ce44e74ef369f5c66bbb85848f2eddd5a7a1cde251e54ccfdd5"
+	)
+
+	var calls int
+	b := newTestRPCBus(t, func(method string) (interface{}, *btcjson.RPCError) {
+		switch method {
+		case "getbestblockhash":
+			return tipHash, nil
+		case "getblockheader":
+			return map[string]interface{}{"hash": tipHash, "height": 700000}, nil
+		case "listsinceblock":
+			return map[string]interface{}{
+				"transactions": []map[string]interface{}{
+					{"txid": pending, "confirmations": 0},
+					{"txid": conflicted, "confirmations": -1},
+					{"txid": lateConfirm, "confirmations": 1},
+				},
+				"lastblock": tipHash,
+			}, nil
+		}
+
+		t.Errorf("unexpected RPC method %s", method)
+		return nil, nil
+	}, &calls)
+	b.firstSeen = cache.New(firstSeenExpiration, firstSeenExpiration)
+
+	if err := b.RecordWalletMempool(); err != nil {
+		t.Fatalf("RecordWalletMempool() error = %v", err)
+	}
+
+	if b.firstSeen.ItemCount() != 1 {
+		t.Errorf("recorded %d transactions, want 1", b.firstSeen.ItemCount())
+	}
+
+	height, found := b.firstSeen.Get(pending)
+	if !found {
+		t.Fatalf("first-seen height of %s not recorded", pending)
+	}
+
+	if height.(int64) != 700000 {
+		t.Errorf("first-seen height = %d, want 700000", height)
+	}
+}
@@ -3,14 +3,38 @@ package bus
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 
-	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/ledgerhq/satstack/protocol"
+	"github.com/ledgerhq/satstack/types"
+	"github.com/ledgerhq/satstack/utils"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 	log "github.com/sirupsen/logrus"
 )
 
-func (b *Bus) SendTransaction(tx string) (*chainhash.Hash, error) {
+// BroadcastResult models the outcome of a transaction broadcast.
+//
+// If the broadcast fails after the transaction has been decoded, only
+// ComputedTxID is set, so that clients can still look the transaction up.
+type BroadcastResult struct {
+	// TxID is the transaction hash returned by the node.
+	TxID string `json:"result"`
+
+	// ComputedTxID is the transaction hash computed locally, before the
+	// broadcast.
+	ComputedTxID string `json:"computed_txid"`
+
+	// TxIDMismatch indicates that TxID and ComputedTxID differ, which
+	// should never happen. It would indicate a bug, or a malleated
+	// transaction.
+	TxIDMismatch bool `json:"txid_mismatch"`
+}
+
+func (b *Bus) SendTransaction(tx string) (*BroadcastResult, error) {
 	// Decode the serialized transaction hex to raw bytes.
 	serializedTx, err := hex.DecodeString(tx)
 	if err != nil {
@@ -31,13 +55,49 @@ func (b *Bus) SendTransaction(tx string) (*chainhash.Hash, error) {
 		return nil, err
 	}
 
-	chainHash, err := b.mainClient.SendRawTransaction(&msgTx, true)
+	// The txid does not commit to the witness data, so it is known before
+	// the transaction is broadcast, even for segwit transactions.
+	computedHash := msgTx.TxHash()
+
+	log.WithFields(log.Fields{
+		"hash": computedHash.String(),
+	}).Info("sendrawtransaction Bridge in progress")
+
+	var chainHash *chainhash.Hash
+	err = b.guard(mempoolRPC, func() (err error) {
+		chainHash, err = b.mainClient.SendRawTransaction(&msgTx, true)
+		return err
+	})
 	if err != nil {
 		log.WithFields(log.Fields{
 			"hex":   tx,
+			"hash":  computedHash.String(),
 			"error": err,
 		}).Error("sendrawtransaction Bridge failed")
-		return nil, err
+		return &BroadcastResult{ComputedTxID: computedHash.String()}, err
+	}
+
+	result := &BroadcastResult{
+		TxID:         chainHash.String(),
+		ComputedTxID: computedHash.String(),
+		TxIDMismatch: !chainHash.IsEqual(&computedHash),
+	}
+
+	if result.TxIDMismatch {
+		log.WithFields(log.Fields{
+			"hex":          tx,
+			"hash":         result.TxID,
+			"computedHash": result.ComputedTxID,
+		}).Error("sendrawtransaction Bridge returned unexpected txid")
+	}
+
+	// Record the first-seen height of the transaction, as part of the
+	// mempool entry lookup. A failure here does not affect the broadcast.
+	if _, err := b.GetMempoolEntry(result.TxID); err != nil {
+		log.WithFields(log.Fields{
+			"hash":  result.TxID,
+			"error": err,
+		}).Warn("Could not record first-seen height")
 	}
 
 	log.WithFields(log.Fields{
@@ -45,5 +105,113 @@ func (b *Bus) SendTransaction(tx string) (*chainhash.Hash, error) {
 		"hash": chainHash.String(),
 	}).Info("sendrawtransaction Bridge successful")
 
-	return chainHash, nil
+	return result, nil
+}
+
+// MempoolAcceptResult models the result of the testmempoolaccept RPC, for a
+// single transaction.
+//
+// Fees is only reported by Bitcoin Core 21.0+, for accepted transactions.
+type MempoolAcceptResult struct {
+	TxID         string             `json:"txid"`
+	WTxID        string             `json:"wtxid,omitempty"`
+	Allowed      bool               `json:"allowed"`
+	VSize        int64              `json:"vsize,omitempty"`
+	Fees         *MempoolAcceptFees `json:"fees,omitempty"`
+	RejectReason string             `json:"reject-reason,omitempty"`
+}
+
+// MempoolAcceptFees models the fees of a transaction accepted by the
+// testmempoolaccept RPC.
+//
+// The effective fee rate accounts for the other transactions of the package
+// that are evaluated together with this one, for ex: a parent bumped by a
+// child (CPFP), listed by wtxid in EffectiveIncludes. It is only reported by
+// Bitcoin Core 25.0+; on older nodes, the fee rate of the transaction alone
+// is used instead, and EffectiveIncludes is empty.
+type MempoolAcceptFees struct {
+	Base              btcutil.Amount `json:"base"`
+	EffectiveFeeRate  *types.FeeRate `json:"effective_fee_rate,omitempty"`
+	EffectiveIncludes []string       `json:"effective_includes,omitempty"`
+}
+
+// rawMempoolAcceptResult is an item of the testmempoolaccept response. The
+// fees are in BTC, and the fee rate in BTC/kvB.
+type rawMempoolAcceptResult struct {
+	MempoolAcceptResult
+	Fees *struct {
+		Base              float64  `json:"base"`
+		EffectiveFeeRate  *float64 `json:"effective-feerate"`
+		EffectiveIncludes []string `json:"effective-includes"`
+	} `json:"fees"`
+}
+
+// TestMempoolAccept checks whether the passed raw transactions would be
+// accepted in the mempool of the node, without broadcasting them.
+//
+// The transactions are tested as a package, so a child transaction may spend
+// the outputs of a parent transaction that precedes it in the list. Package
+// validation requires Bitcoin Core 22.0+. Older nodes only accept a single
+// transaction.
+//
+// The results are returned in the same order as the passed transactions.
+func (b *Bus) TestMempoolAccept(txs []string) ([]MempoolAcceptResult, error) {
+	for _, tx := range txs {
+		if _, err := hex.DecodeString(tx); err != nil {
+			log.WithFields(log.Fields{
+				"hex":   tx,
+				"error": err,
+			}).Error("Could not decode transaction hex")
+			return nil, err
+		}
+	}
+
+	rawTxs, err := json.Marshal(txs)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	err = b.guard(mempoolRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest(
+			"testmempoolaccept", []json.RawMessage{rawTxs})
+		return err
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"count": len(txs),
+			"error": err,
+		}).Error("testmempoolaccept Bridge failed")
+		return nil, fmt.Errorf("%s: %w", ErrTestMempoolAccept, err)
+	}
+
+	var rawResults []rawMempoolAcceptResult
+	if err := json.Unmarshal(raw, &rawResults); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrTestMempoolAccept, err)
+	}
+
+	results := make([]MempoolAcceptResult, len(rawResults))
+	for i, rawResult := range rawResults {
+		results[i] = rawResult.MempoolAcceptResult
+
+		if rawResult.Fees == nil {
+			continue
+		}
+
+		fees := MempoolAcceptFees{
+			Base:              utils.ParseSmallestUnit(rawResult.Fees.Base, b.Decimals),
+			EffectiveIncludes: rawResult.Fees.EffectiveIncludes,
+		}
+
+		if rate := rawResult.Fees.EffectiveFeeRate; rate != nil {
+			feeRate := protocol.FeeRateFromKvB(utils.ParseSmallestUnit(*rate, b.Decimals))
+			fees.EffectiveFeeRate = &feeRate
+		} else {
+			fees.EffectiveFeeRate = protocol.FeeRateForVSize(fees.Base, rawResult.VSize)
+		}
+
+		results[i].Fees = &fees
+	}
+
+	return results, nil
 }
@@ -0,0 +1,111 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CheckReadiness checks, in a single pass, that SatStack is ready to serve
+// requests:
+//   - the node is reachable,
+//   - the node is not in initial block download,
+//   - the SatStack wallet is loaded,
+//   - the txindex and prune settings of the node did not change since
+//     SatStack started.
+//
+// The reason of the first failed check is reported.
+func (b *Bus) CheckReadiness() *Readiness {
+	return evaluateReadiness(b.probeReadiness(), b.Pruned, b.TxIndex)
+}
+
+// readinessProbe is the state of the node observed by CheckReadiness.
+type readinessProbe struct {
+	nodeErr              error
+	initialBlockDownload bool
+	pruned               bool
+	walletErr            error
+	txIndexErr           error
+	txIndex              bool
+}
+
+// probeReadiness queries the state of the node. It stops at the first check
+// that fails, leaving the remaining fields unset.
+func (b *Bus) probeReadiness() readinessProbe {
+	var probe readinessProbe
+
+	var raw json.RawMessage
+	probe.nodeErr = b.guard(chainRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("getblockchaininfo", nil)
+		return err
+	})
+	if probe.nodeErr != nil {
+		return probe
+	}
+
+	var info struct {
+		InitialBlockDownload bool `json:"initialblockdownload"`
+		Pruned               bool `json:"pruned"`
+	}
+
+	if probe.nodeErr = json.Unmarshal(raw, &info); probe.nodeErr != nil {
+		return probe
+	}
+
+	probe.initialBlockDownload = info.InitialBlockDownload
+	probe.pruned = info.Pruned
+
+	if probe.initialBlockDownload {
+		return probe
+	}
+
+	probe.walletErr = b.guard(walletRPC, func() error {
+		_, err := b.mainClient.GetWalletInfo()
+		return err
+	})
+	if probe.walletErr != nil || probe.pruned != b.Pruned {
+		return probe
+	}
+
+	probe.txIndexErr = b.guard(chainRPC, func() (err error) {
+		probe.txIndex, err = txIndexEnabled(b.mainClient)
+		return err
+	})
+
+	return probe
+}
+
+// evaluateReadiness decides whether SatStack is ready, from the observed
+// state of the node and the txindex and prune settings detected at startup.
+func evaluateReadiness(probe readinessProbe, pruned bool, txIndex bool) *Readiness {
+	if probe.nodeErr != nil {
+		return notReady(fmt.Sprintf("node unreachable: %s", probe.nodeErr))
+	}
+
+	if probe.initialBlockDownload {
+		return notReady("node in initial block download")
+	}
+
+	if probe.walletErr != nil {
+		return notReady(fmt.Sprintf("wallet %s not loaded: %s", walletName, probe.walletErr))
+	}
+
+	if probe.pruned != pruned {
+		return notReady(fmt.Sprintf(
+			"node prune setting changed: expected %t, got %t", pruned, probe.pruned))
+	}
+
+	if probe.txIndexErr != nil {
+		return notReady(fmt.Sprintf("%s: %s", ErrFailedToDetectTxIndex, probe.txIndexErr))
+	}
+
+	if probe.txIndex != txIndex {
+		return notReady(fmt.Sprintf(
+			"node txindex setting changed: expected %t, got %t", txIndex, probe.txIndex))
+	}
+
+	return &Readiness{Ready: true}
+}
+
+func notReady(reason string) *Readiness {
+	return &Readiness{Ready: false, Reason: reason}
+}
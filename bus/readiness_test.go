@@ -0,0 +1,63 @@
+package bus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+func TestEvaluateReadiness(t *testing.T) {
+	walletErr := btcjson.NewRPCError(btcjson.ErrRPCWalletNotFound, "Requested wallet does not exist or is not loaded")
+
+	tests := []struct {
+		name  string
+		probe readinessProbe
+		want  *Readiness
+	}{
+		{
+			name:  "ready",
+			probe: readinessProbe{txIndex: true},
+			want:  &Readiness{Ready: true},
+		},
+		{
+			name:  "node down",
+			probe: readinessProbe{nodeErr: errors.New("connection refused")},
+			want:  notReady("node unreachable: connection refused"),
+		},
+		{
+			name:  "in initial block download",
+			probe: readinessProbe{initialBlockDownload: true},
+			want:  notReady("node in initial block download"),
+		},
+		{
+			name:  "wallet not loaded",
+			probe: readinessProbe{walletErr: walletErr},
+			want:  notReady("wallet satstack not loaded: " + walletErr.Error()),
+		},
+		{
+			name:  "prune setting changed",
+			probe: readinessProbe{pruned: true, txIndex: true},
+			want:  notReady("node prune setting changed: expected false, got true"),
+		},
+		{
+			name:  "txindex undetected",
+			probe: readinessProbe{txIndexErr: errors.New("timeout")},
+			want:  notReady(ErrFailedToDetectTxIndex.Error() + ": timeout"),
+		},
+		{
+			name:  "txindex setting changed",
+			probe: readinessProbe{txIndex: false},
+			want:  notReady("node txindex setting changed: expected true, got false"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateReadiness(tt.probe, false, true)
+			if *got != *tt.want {
+				t.Errorf("evaluateReadiness() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package bus
 
+import "github.com/btcsuite/btcutil"
+
 // Status indicates the state of LSS with regards to the readiness of the
 // connected Bitcoin Core node.
 type Status string
@@ -47,3 +49,101 @@ type ExplorerStatus struct {
 	SyncProgress *float64 `json:"sync_progress,omitempty"`
 	ScanProgress *float64 `json:"scan_progress,omitempty"`
 }
+
+// ExplorerHealth represents the structure of payload returned by GetHealth
+// service method.
+type ExplorerHealth struct {
+	Status   string `json:"Status"`
+	TipStale bool   `json:"tip_stale"`
+}
+
+// IBDEstimate represents the structure of payload returned by the initial
+// block download ETA endpoint.
+//
+// ETA is a human-readable duration, or ETAUnknown if there is not enough
+// data to estimate it yet. RemainingSeconds and CompletionTime (RFC3339) are
+// only set if the ETA is known.
+type IBDEstimate struct {
+	InitialBlockDownload bool    `json:"initial_block_download"`
+	Blocks               int64   `json:"blocks"`
+	Headers              int64   `json:"headers"`
+	VerificationProgress float64 `json:"verification_progress"`
+	ETA                  string  `json:"eta"`
+	RemainingSeconds     *int64  `json:"remaining_seconds,omitempty"`
+	CompletionTime       string  `json:"completion_time,omitempty"`
+}
+
+// Readiness represents the structure of payload returned by the readiness
+// probe. Reason is only set if SatStack is not ready.
+type Readiness struct {
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// DeepHealth represents the structure of payload returned by GetDeepHealth
+// service method.
+//
+// Status is either HealthPass, HealthSkip or HealthFail. DurationMs is the
+// time taken to fetch and decode TxHash end-to-end.
+type DeepHealth struct {
+	Status     string `json:"status"`
+	TxHash     string `json:"tx_hash,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+const (
+	HealthPass = "PASS"
+	HealthSkip = "SKIP" // the wallet has no transaction to check
+	HealthFail = "FAIL"
+)
+
+// ValidationState describes how much of the chain was fully validated by the
+// node, to help clients decide how much they should trust its history.
+//
+// Bitcoin Core skips script verification of the ancestors of a hardcoded
+// assumevalid block. Every other consensus rule (proof-of-work, amounts,
+// double-spends) is still enforced for all blocks, and blocks after the
+// assumevalid block are fully validated. Recent history is therefore not
+// affected, unless the node is still in initial block download.
+//
+// The -assumevalid option is not exposed over RPC, so AssumeValid only
+// reflects the default of the chain, i.e., true on mainnet and testnet, and
+// false on regtest. It cannot detect an override in bitcoin.conf.
+type ValidationState struct {
+	Chain                string  `json:"chain"`
+	Blocks               int64   `json:"blocks"`
+	Headers              int64   `json:"headers"`
+	InitialBlockDownload bool    `json:"initial_block_download"`
+	VerificationProgress float64 `json:"verification_progress"`
+	AssumeValid          bool    `json:"assume_valid"`
+}
+
+// OutputStatus describes the conditions of an unspent output of the wallet,
+// that are not reported by the listunspent RPC.
+type OutputStatus struct {
+	Coinbase       bool // created by a coinbase transaction
+	Locked         bool // locked with the lockunspent RPC
+	SpentInMempool bool // spent by an unconfirmed transaction
+}
+
+// RelayPolicy summarizes the relay policy of the node, to help clients
+// predict whether their transactions will be relayed. Fee rates are in
+// satoshis per kvB.
+//
+// The -acceptnonstdtxn option is not exposed over RPC, so RequireStandard
+// only reflects the default of the chain, i.e., true on mainnet and regtest,
+// and false on testnet.
+//
+// FullRBF, PermitBareMultisig and MaxDataCarrierSize are only reported by
+// recent versions of Bitcoin Core, and are omitted otherwise.
+type RelayPolicy struct {
+	MinRelayFee         btcutil.Amount `json:"min_relay_fee"`
+	IncrementalRelayFee btcutil.Amount `json:"incremental_relay_fee"`
+	MempoolMinFee       btcutil.Amount `json:"mempool_min_fee"`
+	LocalRelay          bool           `json:"local_relay"`
+	RequireStandard     bool           `json:"require_standard"`
+	FullRBF             *bool          `json:"full_rbf,omitempty"`
+	PermitBareMultisig  *bool          `json:"permit_bare_multisig,omitempty"`
+	MaxDataCarrierSize  *int64         `json:"max_data_carrier_size,omitempty"`
+}
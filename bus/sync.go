@@ -0,0 +1,212 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcutil"
+	"github.com/ledgerhq/satstack/protocol"
+	"github.com/ledgerhq/satstack/types"
+	"github.com/ledgerhq/satstack/utils"
+)
+
+// maxConfirmations is the value of the maxconf argument of listunspent, to
+// list the outputs regardless of their confirmations.
+const maxConfirmations = 9999999
+
+// AccountSnapshot models the raw data required to synchronize an account,
+// fetched from the node in a single batch of RPC requests.
+type AccountSnapshot struct {
+	Info         *btcjson.GetBlockChainInfoResult
+	UTXOs        []types.UTXO
+	Transactions []btcjson.ListTransactionsResult
+	LastBlock    string
+	Fees         map[int64]btcutil.Amount
+}
+
+// GetAccountSnapshot fetches the chain tip, the UTXOs of the addresses, the
+// wallet transactions since the given block (or all, if nil), and the fee
+// estimates for the given targets.
+//
+// The requests are sent as a single JSON-RPC batch, on a dedicated client,
+// to minimize round-trips to the node. ErrRangeTooLarge is returned if the
+// block is more than MaxBlockRange blocks below the chain tip.
+func (b *Bus) GetAccountSnapshot(addresses []string, sinceBlock *string, targets []int64, mode string) (*AccountSnapshot, error) {
+	var addrs []btcutil.Address
+	for _, address := range addresses {
+		addr, err := btcutil.DecodeAddress(address, b.Params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address '%s': %w", address, err)
+		}
+		addrs = append(addrs, addr)
+	}
+
+	sinceBlockHash, err := parseOptionalChainHash(sinceBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.checkSinceBlockRange(sinceBlockHash); err != nil {
+		return nil, err
+	}
+
+	client, err := b.ClientFactory()
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Shutdown()
+
+	batch := client.Batch()
+
+	infoFuture := batch.GetBlockChainInfoAsync()
+	unspentFuture := batch.ListUnspentMinMaxAddressesAsync(0, maxConfirmations, addrs)
+	sinceFuture := batch.ListSinceBlockMinConfWatchOnlyAsync(sinceBlockHash, 1, true)
+
+	feeFutures := make([]rpcclient.FutureEstimateSmartFeeResult, len(targets))
+	for i, target := range targets {
+		feeFutures[i] = batch.EstimateSmartFeeAsync(target, getMode(mode))
+	}
+
+	if err := b.guard(walletRPC, batch.Send); err != nil {
+		return nil, err
+	}
+
+	info, err := infoFuture.Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	unspent, err := unspentFuture.Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	since, err := sinceFuture.Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	fees := make(map[int64]btcutil.Amount, len(targets))
+	for i, target := range targets {
+		fee, err := feeFutures[i].Receive()
+		fees[target] = b.smartFeeRate(fee, err, target, mode)
+	}
+
+	utxos := make([]types.UTXO, 0, len(unspent))
+	for _, result := range unspent {
+		utxos = append(utxos, b.utxoFromResult(result))
+	}
+
+	return &AccountSnapshot{
+		Info:         info,
+		UTXOs:        utxos,
+		Transactions: since.Transactions,
+		LastBlock:    since.LastBlock,
+		Fees:         fees,
+	}, nil
+}
+
+// utxoFromResult converts a result of the listunspent RPC to a types.UTXO.
+func (b *Bus) utxoFromResult(result btcjson.ListUnspentResult) types.UTXO {
+	return types.UTXO{
+		OutputHash:    result.TxID,
+		OutputIndex:   result.Vout,
+		Value:         utils.ParseSmallestUnit(result.Amount, b.Decimals),
+		Address:       result.Address,
+		ScriptHex:     result.ScriptPubKey,
+		ScriptType:    protocol.ScriptType(result.ScriptPubKey),
+		Confirmations: result.Confirmations,
+	}
+}
+
+// SinceBlockResult models the wallet transactions added and removed since a
+// given block, as returned by the listsinceblock RPC.
+//
+// Removed contains the transactions of the blocks that were disconnected by
+// a reorg, and are no longer confirmed in the main chain.
+type SinceBlockResult struct {
+	Transactions []btcjson.ListTransactionsResult `json:"transactions"`
+	Removed      []btcjson.ListTransactionsResult `json:"removed"`
+	LastBlock    string                           `json:"lastblock"`
+}
+
+// ListSinceBlock returns the wallet transactions, including watch-only ones,
+// since the given block (or all, if nil), along with the transactions removed
+// by a reorg. ErrRangeTooLarge is returned if the block is more than
+// MaxBlockRange blocks below the chain tip.
+//
+// The btcjson.ListSinceBlockResult type lacks the removed field, so the RPC
+// response is decoded manually.
+func (b *Bus) ListSinceBlock(sinceBlock *string) (*SinceBlockResult, error) {
+	sinceBlockHash, err := parseOptionalChainHash(sinceBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.checkSinceBlockRange(sinceBlockHash); err != nil {
+		return nil, err
+	}
+
+	blockHashParam := json.RawMessage(`""`)
+	if sinceBlockHash != nil {
+		blockHashParam = json.RawMessage(`"` + sinceBlockHash.String() + `"`)
+	}
+
+	var raw json.RawMessage
+	err = b.guard(walletRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("listsinceblock", []json.RawMessage{
+			blockHashParam,
+			json.RawMessage("1"),    // target_confirmations
+			json.RawMessage("true"), // include_watchonly
+			json.RawMessage("true"), // include_removed
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result SinceBlockResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// checkSinceBlockRange applies CheckBlockRange to a scan of the wallet
+// transactions since the given block, which covers the blocks from it up to
+// the chain tip.
+//
+// A nil block requests the whole wallet history, as for the first
+// synchronization of an account, and is not bounded.
+func (b *Bus) checkSinceBlockRange(sinceBlock *chainhash.Hash) error {
+	if sinceBlock == nil {
+		return nil
+	}
+
+	header, err := b.GetBlockHeader(sinceBlock)
+	if err != nil {
+		return err
+	}
+
+	tipHeight, err := b.GetBlockCount()
+	if err != nil {
+		return err
+	}
+
+	return b.CheckBlockRange(tipHeight - int64(header.Height))
+}
+
+// parseOptionalChainHash parses a hash that may be omitted.
+func parseOptionalChainHash(hash *string) (*chainhash.Hash, error) {
+	if hash == nil {
+		return nil, nil
+	}
+
+	return utils.ParseChainHash(*hash)
+}
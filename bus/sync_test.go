@@ -0,0 +1,139 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+)
+
+// newTestRPCBus returns a Bus whose RPC client talks to a fake node, which
+// answers each method with the result returned by respond. The number of
+// requests received by the node is tracked in calls.
+func newTestRPCBus(t *testing.T, respond func(method string) (interface{}, *btcjson.RPCError), calls *int) *Bus {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Method string          `json:"method"`
+			ID     json.RawMessage `json:"id"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("invalid JSON-RPC request: %v", err)
+			return
+		}
+
+		*calls++
+		result, rpcErr := respond(request.Method)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": result,
+			"error":  rpcErr,
+			"id":     request.ID,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	connCfg := &rpcclient.ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}
+
+	client, err := rpcclient.New(connCfg, nil)
+	if err != nil {
+		t.Fatalf("rpcclient.New() error = %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+
+	return &Bus{mainClient: client, connCfg: connCfg, MaxBlockRange: defaultMaxBlockRange}
+}
+
+func TestCheckSinceBlockRange(t *testing.T) {
+	const tipHeight = 700000
+
+	sinceBlock, _ := chainhash.NewHashFromStr("0000000000000000000b4d0b2e8e7c2a3a2e2d6d8a3e8f7a6c5b4a3928171615")
+
+	tests := []struct {
+		name        string
+		sinceBlock  *chainhash.Hash
+		sinceHeight int64
+		headerErr   *btcjson.RPCError
+		wantCalls   int
+		wantErr     error
+	}{
+		{
+			name:      "whole history",
+			wantCalls: 0,
+		},
+		{
+			name:        "recent block",
+			sinceBlock:  sinceBlock,
+			sinceHeight: tipHeight - 6,
+			wantCalls:   2,
+		},
+		{
+			name:        "at the maximum range",
+			sinceBlock:  sinceBlock,
+			sinceHeight: tipHeight - defaultMaxBlockRange,
+			wantCalls:   2,
+		},
+		{
+			name:        "above the maximum range",
+			sinceBlock:  sinceBlock,
+			sinceHeight: tipHeight - defaultMaxBlockRange - 1,
+			wantCalls:   2,
+			wantErr:     ErrRangeTooLarge,
+		},
+		{
+			name:       "unknown block",
+			sinceBlock: sinceBlock,
+			headerErr:  &btcjson.RPCError{Code: btcjson.ErrRPCInvalidAddressOrKey, Message: "Block not found"},
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			b := newTestRPCBus(t, func(method string) (interface{}, *btcjson.RPCError) {
+				switch method {
+				case "getblockheader":
+					if tt.headerErr != nil {
+						return nil, tt.headerErr
+					}
+
+					return map[string]interface{}{"height": tt.sinceHeight}, nil
+				case "getblockcount":
+					return tipHeight, nil
+				default:
+					t.Errorf("unexpected RPC method %s", method)
+					return nil, nil
+				}
+			}, &calls)
+
+			err := b.checkSinceBlockRange(tt.sinceBlock)
+
+			switch {
+			case tt.headerErr != nil:
+				var rpcErr *btcjson.RPCError
+				if !errors.As(err, &rpcErr) || rpcErr.Code != tt.headerErr.Code {
+					t.Errorf("checkSinceBlockRange() error = %v, want %v", err, tt.headerErr)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Errorf("checkSinceBlockRange() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("checkSinceBlockRange() sent %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
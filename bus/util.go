@@ -11,8 +11,18 @@ import (
 const fallbackFee = btcutil.Amount(1)
 
 func (b *Bus) EstimateSmartFee(target int64, mode string) btcutil.Amount {
-	fee, err := b.mainClient.EstimateSmartFee(target, getMode(mode))
+	var fee *btcjson.EstimateSmartFeeResult
+	err := b.guard(mempoolRPC, func() (err error) {
+		fee, err = b.mainClient.EstimateSmartFee(target, getMode(mode))
+		return err
+	})
 
+	return b.smartFeeRate(fee, err, target, mode)
+}
+
+// smartFeeRate converts the result of the estimatesmartfee RPC to a fee rate
+// in the smallest unit of the currency per kvB.
+func (b *Bus) smartFeeRate(fee *btcjson.EstimateSmartFeeResult, err error, target int64, mode string) btcutil.Amount {
 	// If failed to get smart fee estimate, fallback to fallbackFee.
 	// Example: if the full-node is a regtest chain, there are normally
 	// no transactions in the mempool to analyze for estimating fees.
@@ -36,7 +46,7 @@ func (b *Bus) EstimateSmartFee(target int64, mode string) btcutil.Amount {
 		return fallbackFee
 	}
 
-	return utils.ParseSatoshi(*fee.FeeRate)
+	return utils.ParseSmallestUnit(*fee.FeeRate, b.Decimals)
 }
 
 func DeriveAddress(client *rpcclient.Client, descriptor string, index int) (*string, error) {
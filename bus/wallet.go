@@ -1,6 +1,7 @@
 package bus
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/btcsuite/btcd/rpcclient"
@@ -15,9 +16,16 @@ import (
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 
 	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 	log "github.com/sirupsen/logrus"
 )
 
+// ListTransactions returns the wallet transactions, including watch-only
+// ones, since the given block (or all, if nil).
+//
+// ErrRangeTooLarge is returned if the block is more than MaxBlockRange blocks
+// below the chain tip.
 func (b *Bus) ListTransactions(blockHash *string) ([]btcjson.ListTransactionsResult, error) {
 	var blockHashNative *chainhash.Hash
 	if blockHash != nil {
@@ -28,7 +36,15 @@ func (b *Bus) ListTransactions(blockHash *string) ([]btcjson.ListTransactionsRes
 		}
 	}
 
-	txs, err := b.mainClient.ListSinceBlockMinConfWatchOnly(blockHashNative, 1, true)
+	if err := b.checkSinceBlockRange(blockHashNative); err != nil {
+		return nil, err
+	}
+
+	var txs *btcjson.ListSinceBlockResult
+	err := b.guard(walletRPC, func() (err error) {
+		txs, err = b.mainClient.ListSinceBlockMinConfWatchOnly(blockHashNative, 1, true)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -36,8 +52,31 @@ func (b *Bus) ListTransactions(blockHash *string) ([]btcjson.ListTransactionsRes
 	return txs.Transactions, nil
 }
 
+// LatestTransactionHash returns the hash of the most recent transaction of
+// the wallet, including watch-only ones.
+func (b *Bus) LatestTransactionHash() (string, error) {
+	var txs []btcjson.ListTransactionsResult
+	err := b.guard(walletRPC, func() (err error) {
+		txs, err = b.mainClient.ListTransactionsCountFromWatchOnly("*", 1, 0, true)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(txs) == 0 {
+		return "", ErrNoWalletTransactions
+	}
+
+	return txs[len(txs)-1].TxID, nil
+}
+
 func (b *Bus) GetTransactionHex(hash *chainhash.Hash) (string, error) {
-	tx, err := b.mainClient.GetTransactionWatchOnly(hash, true)
+	var tx *btcjson.GetTransactionResult
+	err := b.guard(walletRPC, func() (err error) {
+		tx, err = b.mainClient.GetTransactionWatchOnly(hash, true)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -89,44 +128,354 @@ func ImportDescriptors(client *rpcclient.Client, descriptors []descriptor) error
 	return nil
 }
 
+// GetTransaction returns the decoded transaction with the given hash. The
+// block of a confirmed transaction is populated as well, using an additional
+// getblockheader RPC call.
 func (b *Bus) GetTransaction(hash string) (*types.Transaction, error) {
-	if b.Cache != nil { // Cache has been enabled at the svc level
-		if tx, found := b.Cache.Get(hash); found {
-			return tx.(*types.Transaction), nil
+	return b.getTransaction(hash, true, nil)
+}
+
+// GetTransactionWithoutBlock returns the decoded transaction with the given
+// hash, without resolving its block.
+//
+// It is meant for the lookup of the outputs spent by the inputs of another
+// transaction, or when the block is already known to the caller, and costs
+// a single RPC call per transaction.
+func (b *Bus) GetTransactionWithoutBlock(hash string) (*types.Transaction, error) {
+	return b.getTransaction(hash, false, nil)
+}
+
+// cachedTransaction is the value stored in a TxCache for a transaction.
+// The hash of its block is retained, so that the block can be resolved
+// lazily, only if requested.
+type cachedTransaction struct {
+	tx        *types.Transaction
+	blockHash string
+}
+
+// getTransaction returns the transaction with the given hash, from the
+// cache if any, and resolves its block if requested. The cache may be nil.
+func (b *Bus) getTransaction(hash string, withBlock bool, txCache *cache.Cache) (*types.Transaction, error) {
+	var cached *cachedTransaction
+
+	if txCache != nil {
+		if value, found := txCache.Get(hash); found {
+			cached = value.(*cachedTransaction)
+		}
+	}
+
+	if cached == nil {
+		var err error
+		cached, err = b.fetchTransaction(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if txCache != nil {
+			txCache.Set(hash, cached, cache.NoExpiration)
 		}
 	}
 
+	// Populate the block using the block header, which is retained by
+	// pruned nodes even when the full block has been discarded.
+	if withBlock && cached.tx.Block == nil && cached.blockHash != "" {
+		block, err := b.blockFromHeader(cached.blockHash)
+		if err != nil {
+			return nil, err
+		}
+
+		cached.tx.Block = block
+	}
+
+	return cached.tx, nil
+}
+
+// fetchTransaction queries the node for the transaction with the given hash,
+// using the transaction index if available, or the wallet otherwise.
+func (b *Bus) fetchTransaction(hash string) (*cachedTransaction, error) {
 	chainHash, err := utils.ParseChainHash(hash)
 	if err != nil {
 		return nil, err
 	}
 
-	var tx *types.Transaction
-
 	switch b.TxIndex {
 	case true:
-		txRaw, err := b.mainClient.GetRawTransaction(chainHash)
+		var txRaw *btcjson.TxRawResult
+		err := b.guard(chainRPC, func() (err error) {
+			txRaw, err = b.mainClient.GetRawTransactionVerbose(chainHash)
+			return err
+		})
 		if err != nil {
 			return nil, err
 		}
 
-		tx = protocol.DecodeMsgTx(txRaw.MsgTx(), b.Params)
+		tx, err := protocol.DecodeRawTransaction(txRaw.Hex, b.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		return &cachedTransaction{tx: tx, blockHash: txRaw.BlockHash}, nil
+
+	default:
+		var txRaw *btcjson.GetTransactionResult
+		err := b.guard(walletRPC, func() (err error) {
+			txRaw, err = b.mainClient.GetTransactionWatchOnly(chainHash, true)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
 
-	case false:
-		txRaw, err := b.mainClient.GetTransactionWatchOnly(chainHash, true)
+		tx, err := protocol.DecodeRawTransaction(txRaw.Hex, b.Params)
 		if err != nil {
 			return nil, err
 		}
 
-		tx, err = protocol.DecodeRawTransaction(txRaw.Hex, b.Params)
+		return &cachedTransaction{tx: tx, blockHash: txRaw.BlockHash}, nil
+	}
+}
+
+// GetBalances returns the balance of the SatStack wallet, split into
+// confirmed, pending and immature buckets, using the getbalances RPC.
+//
+// The SatStack wallet is watch-only. Legacy wallets report the balance of
+// watch-only outputs separately, while descriptor wallets created with
+// private keys disabled report it as their own balance.
+func (b *Bus) GetBalances() (*types.Balances, error) {
+	var balances *btcjson.GetBalancesResult
+	err := b.guard(walletRPC, func() (err error) {
+		balances, err = b.mainClient.GetBalances()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	details := balances.Mine
+	if balances.WatchOnly != nil {
+		details = *balances.WatchOnly
+	}
+
+	return &types.Balances{
+		Confirmed: utils.ParseSmallestUnit(details.Trusted, b.Decimals),
+		Pending:   utils.ParseSmallestUnit(details.UntrustedPending, b.Decimals),
+		Immature:  utils.ParseSmallestUnit(details.Immature, b.Decimals),
+	}, nil
+}
+
+// getRawTransaction returns the transaction with the given hash, using the
+// getrawtransaction RPC. It requires the transaction index, unless the
+// transaction is in the mempool.
+func (b *Bus) getRawTransaction(hash *chainhash.Hash) (*btcutil.Tx, error) {
+	var tx *btcutil.Tx
+	err := b.guard(chainRPC, func() (err error) {
+		tx, err = b.mainClient.GetRawTransaction(hash)
+		return err
+	})
+
+	return tx, err
+}
+
+// GetMsgTx returns the deserialized transaction with the given hash.
+//
+// Like GetTransaction, it relies on the transaction index if available, and
+// on the wallet otherwise.
+func (b *Bus) GetMsgTx(hash *chainhash.Hash) (*wire.MsgTx, error) {
+	if b.TxIndex {
+		tx, err := b.getRawTransaction(hash)
 		if err != nil {
 			return nil, err
 		}
+
+		return tx.MsgTx(), nil
+	}
+
+	txHex, err := b.GetTransactionHex(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return protocol.DeserializeMsgTx(txHex)
+}
+
+// ListUnspent returns all the unspent outputs of the SatStack wallet,
+// including the unconfirmed ones.
+func (b *Bus) ListUnspent() ([]types.UTXO, error) {
+	var results []btcjson.ListUnspentResult
+	err := b.guard(walletRPC, func() (err error) {
+		results, err = b.mainClient.ListUnspentMinMax(0, maxConfirmations)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]types.UTXO, 0, len(results))
+	for _, result := range results {
+		utxos = append(utxos, b.utxoFromResult(result))
+	}
+
+	return utxos, nil
+}
+
+// GetOutputStatuses returns the status of each of the given unspent outputs,
+// indexed by outpoint.
+//
+// The outputs are looked up with gettxout, including the mempool, which
+// reports whether they were created by a coinbase transaction, and returns
+// nothing if they are spent by an unconfirmed transaction. The requests are
+// sent as a single JSON-RPC batch, along with listlockunspent.
+func (b *Bus) GetOutputStatuses(utxos []types.UTXO) (map[types.OutputIdentifier]OutputStatus, error) {
+	client, err := b.ClientFactory()
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Shutdown()
+
+	batch := client.Batch()
+
+	lockedFuture := batch.ListLockUnspentAsync()
+
+	txOutFutures := make([]rpcclient.FutureGetTxOutResult, len(utxos))
+	for i, utxo := range utxos {
+		txHash, err := utils.ParseChainHash(utxo.OutputHash)
+		if err != nil {
+			return nil, err
+		}
+
+		txOutFutures[i] = batch.GetTxOutAsync(txHash, utxo.OutputIndex, true)
+	}
+
+	if err := b.guard(walletRPC, batch.Send); err != nil {
+		return nil, err
+	}
+
+	locked, err := lockedFuture.Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	lockedSet := make(map[types.OutputIdentifier]bool, len(locked))
+	for _, outpoint := range locked {
+		lockedSet[types.OutputIdentifier{
+			Hash:  outpoint.Hash.String(),
+			Index: outpoint.Index,
+		}] = true
+	}
+
+	result := make(map[types.OutputIdentifier]OutputStatus, len(utxos))
+	for i, utxo := range utxos {
+		txOut, err := txOutFutures[i].Receive()
+		if err != nil {
+			return nil, err
+		}
+
+		outpoint := types.OutputIdentifier{Hash: utxo.OutputHash, Index: utxo.OutputIndex}
+		result[outpoint] = OutputStatus{
+			Coinbase:       txOut != nil && txOut.Coinbase,
+			Locked:         lockedSet[outpoint],
+			SpentInMempool: txOut == nil,
+		}
+	}
+
+	return result, nil
+}
+
+// GetTransactionBlock returns the block of the main chain that includes the
+// transaction with the given hash, or nil if the transaction is unconfirmed.
+func (b *Bus) GetTransactionBlock(hash *chainhash.Hash) (*types.Block, error) {
+	var blockHash string
+
+	if b.TxIndex {
+		err := b.guard(chainRPC, func() error {
+			txRaw, err := b.mainClient.GetRawTransactionVerbose(hash)
+			if err == nil {
+				blockHash = txRaw.BlockHash
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := b.guard(walletRPC, func() error {
+			txRaw, err := b.mainClient.GetTransactionWatchOnly(hash, true)
+			if err == nil {
+				blockHash = txRaw.BlockHash
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if blockHash == "" {
+		return nil, nil
+	}
+
+	return b.blockFromHeader(blockHash)
+}
+
+// blockFromHeader returns the minimal block information of the block with
+// the given hash, using the getblockheader RPC.
+func (b *Bus) blockFromHeader(hash string) (*types.Block, error) {
+	blockHash, err := chainhash.NewHashFromStr(hash)
+	if err != nil {
+		return nil, fmt.Errorf("%s (%s): %w", ErrMalformedChainHash, hash, err)
+	}
+
+	header, err := b.GetBlockHeader(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return blockFromHeaderResult(header), nil
+}
+
+// WalletDescriptor models a descriptor imported in the wallet, as returned by
+// the listdescriptors RPC.
+type WalletDescriptor struct {
+	Descriptor string `json:"desc"`
+	Timestamp  int64  `json:"timestamp"`
+	Active     bool   `json:"active"`
+	Internal   *bool  `json:"internal,omitempty"`
+	Range      []int  `json:"range,omitempty"`
+	Next       *int   `json:"next,omitempty"`
+}
+
+// ListDescriptors returns the descriptors imported in the SatStack wallet,
+// using the listdescriptors RPC.
+//
+// Private descriptors are only included if private is set to true. Since the
+// SatStack wallet is watch-only, the RPC will fail in such a case, unless the
+// wallet was created outside of SatStack.
+//
+// The listdescriptors RPC is only available for descriptor wallets.
+func (b *Bus) ListDescriptors(private bool) ([]WalletDescriptor, error) {
+	params := []json.RawMessage{}
+	if private {
+		params = append(params, json.RawMessage("true"))
+	}
+
+	var raw json.RawMessage
+	err := b.guard(walletRPC, func() (err error) {
+		raw, err = b.mainClient.RawRequest("listdescriptors", params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrListDescriptors, err)
+	}
+
+	var result struct {
+		WalletName  string             `json:"wallet_name"`
+		Descriptors []WalletDescriptor `json:"descriptors"`
 	}
 
-	if b.Cache != nil {
-		b.Cache.Set(hash, tx, cache.NoExpiration)
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrListDescriptors, err)
 	}
 
-	return tx, nil
+	return result.Descriptors, nil
 }
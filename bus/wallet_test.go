@@ -0,0 +1,68 @@
+package bus
+
+import (
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// TestGetTransactionBlockResolution checks that the block of a cached
+// transaction is only resolved when requested. The Bus has no RPC client,
+// so any call to the node would panic.
+func TestGetTransactionBlockResolution(t *testing.T) {
+	const hash = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+	txCache := (&Bus{}).NewTxCache()
+	txCache.cache.Set(hash, &cachedTransaction{
+		tx:        &types.Transaction{Hash: hash},
+		blockHash: "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+	}, cache.NoExpiration)
+
+	tx, err := txCache.GetTransactionWithoutBlock(hash)
+	if err != nil {
+		t.Fatalf("GetTransactionWithoutBlock() error = %v", err)
+	}
+
+	if tx.Block != nil {
+		t.Errorf("GetTransactionWithoutBlock() resolved block %v, want nil", tx.Block)
+	}
+
+	block := &types.Block{Hash: "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"}
+	tx.Block = block
+
+	tx, err = txCache.GetTransaction(hash)
+	if err != nil {
+		t.Fatalf("GetTransaction() error = %v", err)
+	}
+
+	if tx.Block != block {
+		t.Errorf("GetTransaction() block = %v, want the already resolved %v", tx.Block, block)
+	}
+}
+
+// TestTxCacheScope checks that transactions cached for a request are not
+// visible to, nor evicted by, other requests.
+func TestTxCacheScope(t *testing.T) {
+	const hash = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+	b := &Bus{}
+	first, second := b.NewTxCache(), b.NewTxCache()
+
+	first.cache.Set(hash, &cachedTransaction{tx: &types.Transaction{Hash: hash}}, cache.NoExpiration)
+	second.Delete(hash)
+
+	if _, found := first.cache.Get(hash); !found {
+		t.Errorf("transaction evicted by another TxCache")
+	}
+
+	if _, found := second.cache.Get(hash); found {
+		t.Errorf("transaction visible in another TxCache")
+	}
+
+	first.Delete(hash)
+	if _, found := first.cache.Get(hash); found {
+		t.Errorf("Delete() did not evict the transaction")
+	}
+}
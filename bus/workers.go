@@ -10,7 +10,6 @@ import (
 	"github.com/btcsuite/btcd/rpcclient"
 
 	"github.com/btcsuite/btcd/btcjson"
-	"github.com/btcsuite/btcutil"
 	"github.com/ledgerhq/satstack/config"
 	"github.com/ledgerhq/satstack/utils"
 	log "github.com/sirupsen/logrus"
@@ -24,6 +23,8 @@ func waitForIBD(b *Bus) error {
 		}
 
 		if info.Blocks != info.Headers {
+			b.ibdProgress.record(time.Now(), info.VerificationProgress)
+
 			log.WithFields(log.Fields{
 				"prefix":   "worker",
 				"count":    fmt.Sprintf("%d/%d", info.Blocks, info.Headers),
@@ -189,10 +190,7 @@ func runTheNumbers(b *Bus) error {
 
 	supply += subsidy * float64(info.Height-(halvingBlocks*i))
 
-	supplyBTC, err := btcutil.NewAmount(supply)
-	if err != nil {
-		return err
-	}
+	supplyBTC := utils.ParseSmallestUnit(supply, b.Decimals)
 
 	log.WithFields(log.Fields{
 		"prefix":         "worker",
@@ -266,6 +264,19 @@ func (b *Bus) Worker(config *config.Configuration) {
 		importDone <- true
 	}()
 
+	go func() {
+		for {
+			time.Sleep(mempoolPollInterval)
+
+			if err := b.RecordWalletMempool(); err != nil {
+				log.WithFields(log.Fields{
+					"prefix": "worker",
+					"error":  err,
+				}).Warn("Failed to record wallet mempool first-seen heights")
+			}
+		}
+	}()
+
 	go func() {
 		defer func() {
 			close(importDone)
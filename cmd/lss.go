@@ -54,6 +54,14 @@ func startup() *svc.Service {
 		return nil
 	}
 
+	if configuration.StaleTip != nil {
+		b.SetStaleTipIntervals(*configuration.StaleTip)
+	}
+
+	if configuration.MaxBlockRange != nil {
+		b.MaxBlockRange = int64(*configuration.MaxBlockRange)
+	}
+
 	log.WithFields(log.Fields{
 		"chain":       b.Chain,
 		"pruned":      b.Pruned,
@@ -65,6 +73,36 @@ func startup() *svc.Service {
 		Bus: b,
 	}
 
+	if configuration.Denylist != nil {
+		denylist, err := config.LoadDenylist(*configuration.Denylist)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"path":  *configuration.Denylist,
+			}).Fatal("Failed to load denylist")
+			return nil
+		}
+
+		log.WithFields(log.Fields{
+			"path":      *configuration.Denylist,
+			"addresses": len(denylist),
+		}).Info("Address denylist loaded")
+
+		s.Denylist = denylist
+	}
+
+	if configuration.Timezone != nil {
+		s.Location = config.LoadTimezone(*configuration.Timezone)
+	}
+
+	if configuration.Finality != nil {
+		s.FinalityDepth = uint64(*configuration.Finality)
+	}
+
+	if configuration.MinConf != nil {
+		s.MinConf = int64(*configuration.MinConf)
+	}
+
 	fortunes.Fortune()
 
 	s.Bus.Worker(configuration)
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Denylist is a set of addresses that must be flagged when they are touched
+// by a transaction. It is a SatStack-side filter, and does not affect the
+// Bitcoin node in any way.
+type Denylist map[string]struct{}
+
+// Contains checks if an address is on the denylist. It is safe to call on a
+// nil Denylist.
+func (d Denylist) Contains(address string) bool {
+	if address == "" {
+		return false
+	}
+
+	_, ok := d[address]
+	return ok
+}
+
+// LoadDenylist reads a denylist file from disk.
+//
+// The file is expected to contain one address per line. Blank lines, and
+// lines starting with # are ignored. Addresses are normalized with
+// normalizeAddress, to match the addresses reported by the node.
+func LoadDenylist(path string) (Denylist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrDenylist, err)
+	}
+
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	denylist := make(Denylist)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		denylist[normalizeAddress(line)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrDenylist, err)
+	}
+
+	return denylist, nil
+}
+
+// bech32Prefixes are the human-readable parts of the segwit addresses of the
+// supported networks, followed by the separator.
+var bech32Prefixes = []string{
+	chaincfg.MainNetParams.Bech32HRPSegwit + "1",
+	chaincfg.TestNet3Params.Bech32HRPSegwit + "1",
+	chaincfg.RegressionNetParams.Bech32HRPSegwit + "1",
+}
+
+// normalizeAddress returns the canonical form of an address, as encoded by
+// the node. Bech32 addresses are case-insensitive, and canonically lowercase,
+// while base58 addresses are case-sensitive, and returned as-is.
+func normalizeAddress(address string) string {
+	lower := strings.ToLower(address)
+	for _, prefix := range bech32Prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return lower
+		}
+	}
+
+	return address
+}
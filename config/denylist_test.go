@@ -0,0 +1,56 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	content := "# Flagged addresses\n" +
+		"\n" +
+		"BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ\n" +
+		"  tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx  \n" +
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH\n"
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	denylist, err := LoadDenylist(path)
+	if err != nil {
+		t.Fatalf("LoadDenylist() error = %v", err)
+	}
+
+	want := Denylist{
+		"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq": {},
+		"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx": {},
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH":         {},
+	}
+	if !reflect.DeepEqual(denylist, want) {
+		t.Errorf("LoadDenylist() = %v, want %v", denylist, want)
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    string
+	}{
+		{"uppercase mainnet bech32", "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+		{"uppercase regtest bech32", "BCRT1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "bcrt1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+		{"lowercase bech32", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+		{"base58 kept as-is", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeAddress(tt.address); got != tt.want {
+				t.Errorf("normalizeAddress() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
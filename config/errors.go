@@ -22,4 +22,8 @@ var (
 	// ErrHomeNotFound indicates that an error was encountered while obtaining
 	// the user's home directory.
 	ErrHomeNotFound = errors.New("home directory not found")
+
+	// ErrDenylist indicates that the address denylist file could not be
+	// read.
+	ErrDenylist = errors.New("failed to load denylist")
 )
@@ -26,6 +26,13 @@ type Configuration struct {
 	TorProxy    string    `json:"torproxy"`
 	NoTLS       bool      `json:"notls"`
 	Accounts    []Account `json:"accounts"`
+	StaleTip    *int      `json:"staletip"` // (?) Number of block intervals after which the tip is stale
+	Denylist    *string   `json:"denylist"` // (?) Path to a file of addresses to flag, one per line
+	Timezone    *string   `json:"timezone"` // (?) IANA timezone to format confirmation times in, for ex: Europe/Paris
+	Finality    *int      `json:"finality"` // (?) Number of confirmations after which a transaction is final
+	MinConf     *int      `json:"minconf"`  // (?) Number of confirmations after which an output is safe to spend
+
+	MaxBlockRange *int `json:"maxblockrange"` // (?) Maximum number of blocks covered by a range scan
 }
 
 type date struct {
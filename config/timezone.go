@@ -0,0 +1,26 @@
+package config
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LoadTimezone returns the location of the IANA timezone with the given name,
+// for ex: Europe/Paris.
+//
+// An invalid timezone is not fatal, since it only affects the display of
+// dates. In this case, a warning is logged and UTC is used instead.
+func LoadTimezone(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"timezone": name,
+		}).Warn("Invalid timezone, falling back to UTC")
+
+		return time.UTC
+	}
+
+	return location
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	tests := []struct {
+		name string
+		tz   string
+		want *time.Location
+	}{
+		{"UTC", "UTC", time.UTC},
+		{"invalid name falls back to UTC", "Not/AZone", time.UTC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LoadTimezone(tt.tz); got.String() != tt.want.String() {
+				t.Errorf("LoadTimezone(%q) = %s, want %s", tt.tz, got, tt.want)
+			}
+		})
+	}
+}
@@ -24,6 +24,22 @@ func (c Configuration) validate() error {
 		return err
 	}
 
+	if c.StaleTip != nil && *c.StaleTip <= 0 {
+		return fmt.Errorf("staletip must be positive: %d", *c.StaleTip)
+	}
+
+	if c.Finality != nil && *c.Finality <= 0 {
+		return fmt.Errorf("finality must be positive: %d", *c.Finality)
+	}
+
+	if c.MinConf != nil && *c.MinConf <= 0 {
+		return fmt.Errorf("minconf must be positive: %d", *c.MinConf)
+	}
+
+	if c.MaxBlockRange != nil && *c.MaxBlockRange <= 0 {
+		return fmt.Errorf("maxblockrange must be positive: %d", *c.MaxBlockRange)
+	}
+
 	for _, account := range c.Accounts {
 		if err := validateStringField("external", account.External); err != nil {
 			return err
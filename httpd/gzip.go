@@ -0,0 +1,103 @@
+package httpd
+
+import (
+	"compress/gzip"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipWriter compresses the response body written by the handlers.
+//
+// The gzip stream is created on the first write, so that empty responses
+// are served without a gzip header.
+type gzipWriter struct {
+	gin.ResponseWriter
+	writer *gzip.Writer
+}
+
+func (g *gzipWriter) Write(data []byte) (int, error) {
+	if g.writer == nil {
+		g.Header().Set("Content-Encoding", "gzip")
+		g.Header().Del("Content-Length")
+		g.writer = gzip.NewWriter(g.ResponseWriter)
+	}
+
+	return g.writer.Write(data)
+}
+
+func (g *gzipWriter) WriteString(s string) (int, error) {
+	return g.Write([]byte(s))
+}
+
+// Flush writes the pending compressed data to the client, for streaming
+// responses.
+func (g *gzipWriter) Flush() {
+	if g.writer != nil {
+		_ = g.writer.Flush()
+	}
+
+	g.ResponseWriter.Flush()
+}
+
+// Gzip is a gin middleware compressing the response body with gzip, if the
+// client accepts it. Otherwise, the response is served uncompressed.
+func Gzip() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Vary", "Accept-Encoding")
+
+		if !acceptsGzip(ctx.GetHeader("Accept-Encoding")) {
+			ctx.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+
+		defer func() {
+			if writer.writer != nil {
+				_ = writer.writer.Close()
+			}
+		}()
+
+		ctx.Next()
+	}
+}
+
+// acceptsGzip parses the value of an Accept-Encoding header, and reports
+// whether the gzip encoding is acceptable, i.e., listed explicitly or with a
+// wildcard, without a zero quality value.
+//
+// An explicit gzip entry takes precedence over the wildcard, regardless of
+// their order in the header.
+func acceptsGzip(header string) bool {
+	var wildcard *float64
+
+	for _, item := range strings.Split(header, ",") {
+		parts := strings.Split(item, ";")
+
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if coding == "gzip" {
+			return quality > 0
+		}
+
+		wildcard = &quality
+	}
+
+	return wildcard != nil && *wildcard > 0
+}
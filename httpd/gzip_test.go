@@ -0,0 +1,102 @@
+package httpd
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"identity", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip;q=0.5", true},
+		{"deflate , gzip ; q=1.0", true},
+		{"gzip;q=0", false},
+		{"gzip;q=0.0, deflate", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"gzip;q=0, *", false},
+		{"*;q=0, gzip", true},
+		{"*, gzip;q=0", false},
+		{"gzip;q=invalid", true},
+		{"x-gzip", false},
+	}
+
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.header); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGzip(t *testing.T) {
+	const body = `{"result":"ok"}`
+
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(Gzip())
+	engine.GET("/", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, body)
+	})
+	engine.GET("/empty", func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+	}{
+		{"compressed", "/", "gzip, deflate", "gzip", body},
+		{"not accepted", "/", "deflate", "", body},
+		{"empty response", "/empty", "gzip", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest("GET", tt.path, nil)
+			request.Header.Set("Accept-Encoding", tt.acceptEncoding)
+
+			engine.ServeHTTP(recorder, request)
+
+			if got := recorder.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want %q", got, "Accept-Encoding")
+			}
+
+			encoding := recorder.Header().Get("Content-Encoding")
+			if encoding != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", encoding, tt.wantEncoding)
+			}
+
+			got := recorder.Body.Bytes()
+			if encoding == "gzip" {
+				reader, err := gzip.NewReader(recorder.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() error = %v", err)
+				}
+
+				if got, err = ioutil.ReadAll(reader); err != nil {
+					t.Fatalf("ReadAll() error = %v", err)
+				}
+			}
+
+			if string(got) != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -1,16 +1,27 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/ledgerhq/satstack/bus"
 	"github.com/ledgerhq/satstack/httpd/svc"
 	"github.com/ledgerhq/satstack/utils"
 
+	"github.com/btcsuite/btcutil"
 	"github.com/gin-gonic/gin"
 )
 
+// GetAddresses is a gin handler (factory) to query the transactions of a
+// list of addresses, since the block_hash query parameter if any.
+//
+// A block_hash more than the maximum block range below the chain tip is
+// rejected with 400 Bad Request.
 func GetAddresses(s svc.AddressesService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		param := ctx.Param("addresses")
@@ -23,8 +34,28 @@ func GetAddresses(s svc.AddressesService) gin.HandlerFunc {
 			blockHash = &blockHashQuery
 		}
 
-		addresses, err := s.GetAddresses(addressList, blockHash)
-		if err != nil {
+		// Address reuse analysis is opt-in.
+		opts := svc.AddressesOptions{
+			Reuse: ctx.Query("reuse") == "true",
+		}
+
+		var err error
+		if opts.MinValue, err = parseOptionalAmount(ctx, "min_value"); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if opts.MaxValue, err = parseOptionalAmount(ctx, "max_value"); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		addresses, err := s.GetAddresses(addressList, blockHash, opts)
+		switch {
+		case errors.Is(err, bus.ErrRangeTooLarge):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
 			ctx.JSON(http.StatusNotFound, err)
 			return
 		}
@@ -48,6 +79,59 @@ func GetAddresses(s svc.AddressesService) gin.HandlerFunc {
 			return *iReceivedAt < *jReceivedAt
 		})
 
+		// Serialize only the fields requested by the client, if any.
+		if fields := parseFields(ctx); fields != nil {
+			txs := make([]map[string]json.RawMessage, 0, len(addresses.Transactions))
+			for _, tx := range addresses.Transactions {
+				selected, err := selectFields(tx, fields)
+				if err != nil {
+					ctx.JSON(http.StatusInternalServerError, err)
+					return
+				}
+
+				txs = append(txs, selected)
+			}
+
+			ctx.JSON(http.StatusOK, gin.H{
+				"truncated": addresses.Truncated,
+				"txs":       txs,
+			})
+			return
+		}
+
 		ctx.JSON(http.StatusOK, addresses)
 	}
 }
+
+// parseOptionalAmount parses the query parameter with the given key as an
+// amount in satoshis. It returns nil if the query parameter is missing.
+func parseOptionalAmount(ctx *gin.Context, key string) (*btcutil.Amount, error) {
+	param := ctx.Query(key)
+	if param == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s '%s'", key, param)
+	}
+
+	amount := btcutil.Amount(value)
+	return &amount, nil
+}
+
+// GetElectrumHistory is a gin handler (factory) to query the history of an
+// address, in the format expected by Electrum-derived clients.
+func GetElectrumHistory(s svc.AddressesService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		address := ctx.Param("addresses")
+
+		history, err := s.GetElectrumHistory(address)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, history)
+	}
+}
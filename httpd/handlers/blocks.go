@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
+	"github.com/ledgerhq/satstack/bus"
 	"github.com/ledgerhq/satstack/httpd/svc"
 	"github.com/ledgerhq/satstack/types"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 // GetBlock gets the current block, or a block by height or hash.
@@ -17,11 +22,24 @@ import (
 //
 // Except for the case where the block reference is "current", the response is
 // a list of 1 element.
+//
+// The optional coinbase query parameter (include, exclude, only) can be used
+// to filter the coinbase transaction out of the block transactions.
 func GetBlock(s svc.BlocksService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		blockRef := ctx.Param("block")
 
-		block, err := s.GetBlock(blockRef)
+		coinbase := types.CoinbaseFilter(ctx.DefaultQuery("coinbase", string(types.CoinbaseInclude)))
+		switch coinbase {
+		case types.CoinbaseInclude, types.CoinbaseExclude, types.CoinbaseOnly:
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid coinbase filter '%s'", coinbase),
+			})
+			return
+		}
+
+		block, err := s.GetBlock(blockRef, coinbase)
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, err)
 			return
@@ -35,3 +53,122 @@ func GetBlock(s svc.BlocksService) gin.HandlerFunc {
 		}
 	}
 }
+
+// GetBlockFees is a gin handler (factory) to query the transactions of a
+// block, each annotated with its fee.
+//
+// The coinbase transaction pays no fee, so its fee is always 0, unlike the
+// transaction endpoints which report the fees collected by the coinbase.
+func GetBlockFees(s svc.BlocksService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		blockRef := ctx.Param("block")
+
+		fees, err := s.GetBlockFees(blockRef)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, fees)
+	}
+}
+
+// GetBlockDelta gets the outpoints spent and created by a block, referenced
+// by height or hash (or "current").
+func GetBlockDelta(s svc.BlocksService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		blockRef := ctx.Param("block")
+
+		delta, err := s.GetBlockDelta(blockRef)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, delta)
+	}
+}
+
+// GetBlockChainMembership checks whether a block, referenced by hash, is on
+// the main chain, or is a stale block known to the node.
+func GetBlockChainMembership(s svc.BlocksService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		blockRef := ctx.Param("block")
+
+		membership, err := s.GetBlockChainMembership(blockRef)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, membership)
+	}
+}
+
+// FindLastCommonBlock is a gin handler (factory) to find the most recent
+// block shared by the client and the node, from a list of block hashes known
+// by the client, newest first.
+func FindLastCommonBlock(s svc.BlocksService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request struct {
+			Hashes []string `json:"hashes" binding:"required"`
+		}
+
+		if err := ctx.BindJSON(&request); err != nil {
+			log.Error("Failed to bind JSON request")
+			ctx.JSON(http.StatusBadRequest, err)
+			return
+		}
+
+		common, err := s.FindLastCommonBlock(request.Hashes)
+		switch {
+		case errors.Is(err, svc.ErrTooManyBlockHashes), errors.Is(err, bus.ErrMalformedChainHash):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			log.WithField("error", err).Error("Failed to find last common block")
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, common)
+	}
+}
+
+// defaultBlockIntervals is the number of blocks GetBlockIntervals looks back,
+// if the block_count query parameter is missing. It amounts to about a day.
+const defaultBlockIntervals = 144
+
+// GetBlockIntervals is a gin handler (factory) to query statistics on the
+// time between recent blocks.
+//
+// A block_count above the maximum block range is rejected with 400 Bad
+// Request.
+func GetBlockIntervals(s svc.BlocksService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		count := int64(defaultBlockIntervals)
+		if param := ctx.Query("block_count"); param != "" {
+			value, err := strconv.ParseInt(param, 10, 64)
+			if err != nil || value <= 0 {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("invalid block_count '%s'", param),
+				})
+				return
+			}
+
+			count = value
+		}
+
+		intervals, err := s.GetBlockIntervals(count)
+		switch {
+		case errors.Is(err, bus.ErrRangeTooLarge):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, intervals)
+	}
+}
@@ -52,3 +52,20 @@ func HasDescriptor(s svc.ControlService) gin.HandlerFunc {
 		})
 	}
 }
+
+func ListDescriptors(s svc.ControlService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		private := ctx.Query("private") == "true"
+
+		descriptors, err := s.ListDescriptors(private)
+		if err != nil {
+			log.WithField("error", err).Error("Failed to list descriptors")
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"descriptors": descriptors,
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ledgerhq/satstack/bus"
 	"github.com/ledgerhq/satstack/httpd/svc"
 
 	"github.com/gin-gonic/gin"
@@ -13,40 +14,106 @@ import (
 
 func GetHealth(s svc.ExplorerService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		err := s.GetHealth()
+		health, err := s.GetHealth()
 		if err != nil {
 			ctx.JSON(http.StatusServiceUnavailable, err)
 			return
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{"Status": "OK"})
+		ctx.JSON(http.StatusOK, health)
 	}
 }
 
-func GetFees(s svc.ExplorerService) gin.HandlerFunc {
+// GetDeepHealth is a gin handler (factory) to run the deep health check,
+// responding with 503 if it failed.
+func GetDeepHealth(s svc.ExplorerService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		blockCounts := ctx.QueryArray("block_count")
-		mode := strings.ToUpper(ctx.Param("mode"))
-		if mode == "" || (mode != "UNSET" && mode != "ECONOMICAL" && mode != "CONSERVATIVE") {
-			mode = "CONSERVATIVE"
+		health := s.GetDeepHealth()
+		if health.Status == bus.HealthFail {
+			ctx.JSON(http.StatusServiceUnavailable, health)
+			return
 		}
 
-		var blockCountsIntegers []int64
-		for _, blockCount := range blockCounts {
-			if value, err := strconv.ParseInt(blockCount, 10, 64); err == nil {
-				blockCountsIntegers = append(blockCountsIntegers, value)
-			}
-		}
+		ctx.JSON(http.StatusOK, health)
+	}
+}
 
-		if len(blockCountsIntegers) == 0 {
-			blockCountsIntegers = append(blockCountsIntegers, 2, 3, 6)
+// GetReadiness is a gin handler (factory) for readiness probes, responding
+// with 503 and the reason if SatStack is not ready to serve requests.
+func GetReadiness(s svc.ExplorerService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		readiness := s.GetReadiness()
+		if !readiness.Ready {
+			ctx.JSON(http.StatusServiceUnavailable, readiness)
+			return
 		}
 
-		fees := s.GetFees(blockCountsIntegers, mode)
+		ctx.JSON(http.StatusOK, readiness)
+	}
+}
+
+func GetFees(s svc.ExplorerService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		targets, mode := parseFeeTargets(ctx)
+
+		fees := s.GetFees(targets, mode)
 		ctx.JSON(http.StatusOK, fees)
 	}
 }
 
+// GetFeeRates returns the fee rate estimates of GetFees, in satoshis per vB,
+// satoshis per kvB and BTC per kB.
+func GetFeeRates(s svc.ExplorerService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		targets, mode := parseFeeTargets(ctx)
+
+		feeRates := s.GetFeeRates(targets, mode)
+		ctx.JSON(http.StatusOK, feeRates)
+	}
+}
+
+// parseFeeTargets returns the confirmation targets of the block_count query
+// parameters, and the estimate mode. Invalid targets are ignored, and the
+// targets default to 2, 3 and 6 blocks.
+func parseFeeTargets(ctx *gin.Context) ([]int64, string) {
+	blockCounts := ctx.QueryArray("block_count")
+	mode := strings.ToUpper(ctx.Param("mode"))
+	if mode == "" || (mode != "UNSET" && mode != "ECONOMICAL" && mode != "CONSERVATIVE") {
+		mode = "CONSERVATIVE"
+	}
+
+	var blockCountsIntegers []int64
+	for _, blockCount := range blockCounts {
+		if value, err := strconv.ParseInt(blockCount, 10, 64); err == nil {
+			blockCountsIntegers = append(blockCountsIntegers, value)
+		}
+	}
+
+	if len(blockCountsIntegers) == 0 {
+		blockCountsIntegers = append(blockCountsIntegers, 2, 3, 6)
+	}
+
+	return blockCountsIntegers, mode
+}
+
+// GetEvictionFee returns the fee rate below which transactions are evicted
+// from, or not accepted in, the mempool.
+func GetEvictionFee(s svc.ExplorerService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		feeRate, full, err := s.GetEvictionFee()
+		if err != nil {
+			ctx.JSON(http.StatusServiceUnavailable, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"eviction_fee_rate": feeRate,
+			"mempool_full":      full,
+			"last_updated":      int32(time.Now().Unix()),
+		})
+	}
+}
+
 func GetTimestamp() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{
@@ -60,3 +127,43 @@ func GetStatus(s svc.ExplorerService) gin.HandlerFunc {
 		ctx.JSON(http.StatusOK, s.GetStatus())
 	}
 }
+
+func GetValidationState(s svc.ExplorerService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		state, err := s.GetValidationState()
+		if err != nil {
+			ctx.JSON(http.StatusServiceUnavailable, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, state)
+	}
+}
+
+// GetRelayPolicy is a gin handler (factory) to query the relay policy of the
+// node.
+func GetRelayPolicy(s svc.ExplorerService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		policy, err := s.GetRelayPolicy()
+		if err != nil {
+			ctx.JSON(http.StatusServiceUnavailable, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, policy)
+	}
+}
+
+// GetIBDEstimate is a gin handler (factory) to query the estimated time
+// remaining until the node completes its initial block download.
+func GetIBDEstimate(s svc.ExplorerService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		estimate, err := s.GetIBDEstimate()
+		if err != nil {
+			ctx.JSON(http.StatusServiceUnavailable, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, estimate)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/httpd/svc"
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/gin-gonic/gin"
+)
+
+// stubExplorerService implements the methods of svc.ExplorerService
+// exercised by the tests. Calling any other method panics.
+type stubExplorerService struct {
+	svc.ExplorerService
+
+	health *bus.DeepHealth
+
+	targets []int64
+	mode    string
+}
+
+func (s *stubExplorerService) GetDeepHealth() *bus.DeepHealth {
+	return s.health
+}
+
+func (s *stubExplorerService) GetFees(targets []int64, mode string) map[string]interface{} {
+	s.targets, s.mode = targets, mode
+	return map[string]interface{}{"2": btcutil.Amount(1000)}
+}
+
+func (s *stubExplorerService) GetFeeRates(targets []int64, mode string) map[string]interface{} {
+	s.targets, s.mode = targets, mode
+	return map[string]interface{}{"2": types.FeeRate{SatPerVByte: 1, SatPerKvB: 1000, BTCPerKB: 0.00001}}
+}
+
+func TestGetDeepHealth(t *testing.T) {
+	tests := []struct {
+		status string
+		want   int
+	}{
+		{bus.HealthPass, http.StatusOK},
+		{bus.HealthSkip, http.StatusOK},
+		{bus.HealthFail, http.StatusServiceUnavailable},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequest("GET", "/health/deep", nil)
+
+		service := &stubExplorerService{health: &bus.DeepHealth{Status: tt.status}}
+		GetDeepHealth(service)(ctx)
+
+		if recorder.Code != tt.want {
+			t.Errorf("GetDeepHealth() with status %s responded %d, want %d",
+				tt.status, recorder.Code, tt.want)
+		}
+	}
+}
+
+func TestParseFeeTargets(t *testing.T) {
+	tests := []struct {
+		query       string
+		wantTargets []int64
+	}{
+		{"", []int64{2, 3, 6}},
+		{"?block_count=1", []int64{1}},
+		{"?block_count=1&block_count=invalid&block_count=144", []int64{1, 144}},
+		{"?block_count=invalid", []int64{2, 3, 6}},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", "/"+tt.query, nil)
+
+		targets, mode := parseFeeTargets(ctx)
+		if !reflect.DeepEqual(targets, tt.wantTargets) {
+			t.Errorf("parseFeeTargets(%q) targets = %v, want %v", tt.query, targets, tt.wantTargets)
+		}
+
+		if mode != "CONSERVATIVE" {
+			t.Errorf("parseFeeTargets(%q) mode = %q, want CONSERVATIVE", tt.query, mode)
+		}
+	}
+}
+
+func TestGetFees(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(svc.ExplorerService) gin.HandlerFunc
+		want    string
+	}{
+		{
+			// Ledger Live expects every target to map to a number.
+			name:    "fees in satoshis per kvB",
+			handler: GetFees,
+			want:    `{"2":1000}`,
+		},
+		{
+			name:    "fee rates in all units",
+			handler: GetFeeRates,
+			want:    `{"2":{"sat_per_vbyte":1,"sat_per_kvb":1000,"btc_per_kb":0.00001}}`,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubExplorerService{}
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest("GET", "/?block_count=2", nil)
+
+			tt.handler(service)(ctx)
+
+			if recorder.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", recorder.Code, http.StatusOK)
+			}
+
+			if !reflect.DeepEqual(service.targets, []int64{2}) {
+				t.Errorf("targets = %v, want [2]", service.targets)
+			}
+
+			if got := recorder.Body.String(); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseFields returns the list of field names specified as a comma-separated
+// value of the fields query parameter. If the query parameter is missing, it
+// returns nil, which means that all fields must be serialized.
+func parseFields(ctx *gin.Context) []string {
+	param := ctx.Query("fields")
+	if param == "" {
+		return nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(param, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// selectFields serializes the passed value, and retains only the top-level
+// JSON keys listed in fields. Unknown field names are ignored.
+//
+// It is meant to reduce the size of responses for bandwidth-sensitive
+// clients, and relies on the JSON tags of the value for the field names.
+func selectFields(value interface{}, fields []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if raw, ok := all[field]; ok {
+			result[field] = raw
+		}
+	}
+
+	return result, nil
+}
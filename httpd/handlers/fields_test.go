@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", nil},
+		{"?fields=", nil},
+		{"?fields=hash", []string{"hash"}},
+		{"?fields=hash,%20confirmations%20,,fees", []string{"hash", "confirmations", "fees"}},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", "/"+tt.query, nil)
+
+		if got := parseFields(ctx); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFields(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	fees := btcutil.Amount(1000)
+	tx := types.Transaction{
+		Hash:          "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		Confirmations: 6,
+		Fees:          &fees,
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   []string
+	}{
+		{
+			name:   "requested fields only",
+			fields: []string{"hash", "confirmations"},
+			want:   []string{"confirmations", "hash"},
+		},
+		{
+			name:   "unknown fields are ignored",
+			fields: []string{"fees", "unknown"},
+			want:   []string{"fees"},
+		},
+		{
+			name:   "no known field",
+			fields: []string{"unknown"},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := selectFields(tx, tt.fields)
+			if err != nil {
+				t.Fatalf("selectFields() error = %v", err)
+			}
+
+			got := make([]string, 0, len(selected))
+			for key := range selected {
+				got = append(got, key)
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectFields() keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	selected, err := selectFields(tx, []string{"hash"})
+	if err != nil {
+		t.Fatalf("selectFields() error = %v", err)
+	}
+
+	if want := `"` + tx.Hash + `"`; string(selected["hash"]) != want {
+		t.Errorf("selectFields() hash = %s, want %s", selected["hash"], want)
+	}
+}
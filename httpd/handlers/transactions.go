@@ -1,13 +1,72 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/gin-gonic/gin"
+	"github.com/ledgerhq/satstack/bus"
 	"github.com/ledgerhq/satstack/httpd/svc"
+	"github.com/ledgerhq/satstack/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// GetTransaction is a gin handler (factory) to query transaction details by
+// hash parameter.
+//
+// The optional pinned_tip query parameter is the hash of the chain tip that
+// confirmations are computed against. If it is no longer on the main chain,
+// the handler responds with 409.
+//
+// With verbose=true, the inputs include their disassembled signature script.
+func GetTransaction(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		var pinnedTip *chainhash.Hash
+		if param := ctx.Query("pinned_tip"); param != "" {
+			var err error
+			pinnedTip, err = utils.ParseChainHash(param)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("invalid pinned_tip '%s'", param),
+				})
+				return
+			}
+		}
+
+		verbose := ctx.Query("verbose") == "true"
+
+		tx, err := s.GetTransactionAtTip(txHash, pinnedTip, verbose)
+		if errors.Is(err, bus.ErrPinnedTipReorged) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		// Serialize only the fields requested by the client, if any.
+		if fields := parseFields(ctx); fields != nil {
+			selected, err := selectFields(tx, fields)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, err)
+				return
+			}
+
+			ctx.JSON(http.StatusOK, selected)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, tx)
+	}
+}
+
 // GetTransactionHex is a gin handler (factory) to query transaction hex
 // by hash parameter.
 func GetTransactionHex(s svc.TransactionsService) gin.HandlerFunc {
@@ -29,6 +88,197 @@ func GetTransactionHex(s svc.TransactionsService) gin.HandlerFunc {
 	}
 }
 
+// GetCanonicalTransaction is a gin handler (factory) to query the canonical
+// representation of a transaction by hash parameter, for display on a
+// hardware wallet.
+func GetCanonicalTransaction(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		canonical, err := s.GetCanonicalTransaction(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, canonical)
+	}
+}
+
+// GetTransactionFlow is a gin handler (factory) to query the value flow of a
+// transaction, by hash parameter, for Sankey-style visualizations.
+func GetTransactionFlow(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		flow, err := s.GetTransactionFlow(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, flow)
+	}
+}
+
+// GetConfirmationDelay is a gin handler (factory) to query the number of
+// blocks it took for a transaction to be confirmed, by hash parameter.
+func GetConfirmationDelay(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		delay, err := s.GetConfirmationDelay(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"transaction_hash":   txHash,
+			"confirmation_delay": delay,
+		})
+	}
+}
+
+// GetBlockAtDepth is a gin handler (factory) to query the block that is
+// depth blocks above the block of a transaction, by hash and depth
+// parameters.
+func GetBlockAtDepth(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		depth, err := strconv.ParseInt(ctx.Param("depth"), 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid depth '%s'", ctx.Param("depth")),
+			})
+			return
+		}
+
+		block, err := s.GetBlockAtDepth(txHash, depth)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, block)
+	}
+}
+
+// GetReplaceability is a gin handler (factory) to query the BIP-0125
+// replaceability of a transaction by hash parameter.
+func GetReplaceability(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		replaceability, err := s.GetReplaceability(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, replaceability)
+	}
+}
+
+// GetMempoolRank is a gin handler (factory) to query the rank of an
+// unconfirmed transaction in the fee-rate-sorted mempool, by hash parameter.
+func GetMempoolRank(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		rank, err := s.GetMempoolRank(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, rank)
+	}
+}
+
+// GetMempoolDescendants is a gin handler (factory) to query the descendants
+// of an unconfirmed transaction in the mempool, by hash parameter.
+func GetMempoolDescendants(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		descendants, err := s.GetMempoolDescendants(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, descendants)
+	}
+}
+
+// GetTransactionFinality is a gin handler (factory) to check whether a
+// transaction, by hash parameter, can be included in the next block given
+// its lock times.
+func GetTransactionFinality(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		finality, err := s.GetTransactionFinality(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, finality)
+	}
+}
+
+// GetInputWeights is a gin handler (factory) to query the weight of each
+// input of a transaction by hash parameter.
+func GetInputWeights(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		weights, err := s.GetInputWeights(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, weights)
+	}
+}
+
+// GetSigHashTypes is a gin handler (factory) to query the sighash types of
+// the signatures of each input of a transaction by hash parameter.
+func GetSigHashTypes(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		sigHashes, err := s.GetSigHashTypes(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, sigHashes)
+	}
+}
+
+// GetTransactionSummary is a gin handler (factory) to query the input and
+// output counts of a transaction by hash parameter, without the full decoded
+// inputs and outputs.
+func GetTransactionSummary(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		summary, err := s.GetTransactionSummary(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, summary)
+	}
+}
+
 func SendTransaction(s svc.TransactionsService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var request struct {
@@ -41,14 +291,46 @@ func SendTransaction(s svc.TransactionsService) gin.HandlerFunc {
 			return
 		}
 
-		txHash, err := s.SendTransaction(request.Transaction)
+		result, err := s.SendTransaction(request.Transaction)
+		if err != nil && result != nil {
+			// The transaction could be decoded, so report its txid along
+			// with the error, in case it was broadcast nonetheless.
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error":         err.Error(),
+				"computed_txid": result.ComputedTxID,
+			})
+			return
+		}
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+func TestMempoolAccept(s svc.TransactionsService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request struct {
+			Transactions []string `json:"txs" binding:"required"`
+		}
+
+		if err := ctx.BindJSON(&request); err != nil {
+			log.Error("Failed to bind JSON request")
+			ctx.JSON(http.StatusBadRequest, err)
+			return
+		}
+
+		results, err := s.TestMempoolAccept(request.Transactions)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, err)
 			return
 		}
 
 		ctx.JSON(http.StatusOK, gin.H{
-			"result": txHash,
+			"result": results,
 		})
 	}
 }
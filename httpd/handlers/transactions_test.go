@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/httpd/svc"
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubTransactionsService implements the methods of svc.TransactionsService
+// exercised by the tests. Calling any other method panics.
+type stubTransactionsService struct {
+	svc.TransactionsService
+
+	broadcast *bus.BroadcastResult
+	block     *types.Block
+	err       error
+}
+
+func (s *stubTransactionsService) SendTransaction(string) (*bus.BroadcastResult, error) {
+	return s.broadcast, s.err
+}
+
+func (s *stubTransactionsService) GetBlockAtDepth(string, int64) (*types.Block, error) {
+	return s.block, s.err
+}
+
+func TestSendTransaction(t *testing.T) {
+	const txid = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+	tests := []struct {
+		name       string
+		service    *stubTransactionsService
+		wantStatus int
+		wantBody   map[string]interface{}
+	}{
+		{
+			name: "broadcast",
+			service: &stubTransactionsService{
+				broadcast: &bus.BroadcastResult{TxID: txid, ComputedTxID: txid},
+			},
+			wantStatus: http.StatusOK,
+			wantBody: map[string]interface{}{
+				"result":        txid,
+				"computed_txid": txid,
+				"txid_mismatch": false,
+			},
+		},
+		{
+			name: "rejected after decoding",
+			service: &stubTransactionsService{
+				broadcast: &bus.BroadcastResult{ComputedTxID: txid},
+				err:       errors.New("txn-mempool-conflict"),
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody: map[string]interface{}{
+				"error":         "txn-mempool-conflict",
+				"computed_txid": txid,
+			},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest("POST", "/transactions/send", strings.NewReader(`{"tx": "00"}`))
+
+			SendTransaction(tt.service)(ctx)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON body %s: %v", recorder.Body.String(), err)
+			}
+
+			for key, want := range tt.wantBody {
+				if body[key] != want {
+					t.Errorf("body[%q] = %v, want %v", key, body[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetBlockAtDepth(t *testing.T) {
+	tests := []struct {
+		name       string
+		depth      string
+		service    *stubTransactionsService
+		wantStatus int
+		wantBody   map[string]interface{}
+	}{
+		{
+			name:  "block mined",
+			depth: "6",
+			service: &stubTransactionsService{
+				block: &types.Block{Hash: "0x00", Height: 106},
+			},
+			wantStatus: http.StatusOK,
+			wantBody: map[string]interface{}{
+				"hash":   "0x00",
+				"height": float64(106),
+			},
+		},
+		{
+			name:  "block not mined yet",
+			depth: "10",
+			service: &stubTransactionsService{
+				err: &bus.ErrBlockHeightOutOfRange{Requested: 110, Tip: 105},
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody: map[string]interface{}{
+				"requested_height": float64(110),
+				"tip_height":       float64(105),
+			},
+		},
+		{
+			name:       "invalid depth",
+			depth:      "six",
+			service:    &stubTransactionsService{},
+			wantStatus: http.StatusBadRequest,
+			wantBody: map[string]interface{}{
+				"error": "invalid depth 'six'",
+			},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest("GET", "/", nil)
+			ctx.Params = gin.Params{{Key: "hash", Value: "00"}, {Key: "depth", Value: tt.depth}}
+
+			GetBlockAtDepth(tt.service)(ctx)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON body %s: %v", recorder.Body.String(), err)
+			}
+
+			for key, want := range tt.wantBody {
+				if body[key] != want {
+					t.Errorf("body[%q] = %v, want %v", key, body[key], want)
+				}
+			}
+		})
+	}
+}
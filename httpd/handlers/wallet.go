@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/httpd/svc"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetBalances is a gin handler (factory) to query the balance of the wallet,
+// split into confirmed, pending and immature buckets.
+func GetBalances(s svc.WalletService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		balances, err := s.GetBalances()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, balances)
+	}
+}
+
+// defaultMaxUTXOs is the maximum number of UTXOs returned by ListUTXOs, if
+// the max_count query parameter is missing.
+const defaultMaxUTXOs = 1000
+
+// ListUTXOs is a gin handler (factory) to query the UTXOs of the wallet.
+//
+// The max_count query parameter bounds the number of UTXOs in the response.
+// Use the truncated and total fields to detect that some were left out.
+//
+// The optional script_type query parameter restricts the response to the
+// UTXOs of a given script type, for ex: witness_v1_taproot.
+func ListUTXOs(s svc.WalletService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		maxCount := defaultMaxUTXOs
+		if param := ctx.Query("max_count"); param != "" {
+			value, err := strconv.Atoi(param)
+			if err != nil || value <= 0 {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("invalid max_count '%s'", param),
+				})
+				return
+			}
+
+			maxCount = value
+		}
+
+		scriptType := ctx.Query("script_type")
+
+		utxos, err := s.ListUTXOs(maxCount, scriptType)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		// Serialize only the fields requested by the client, if any.
+		if fields := parseFields(ctx); fields != nil {
+			selectedUTXOs := make([]map[string]json.RawMessage, 0, len(utxos.UTXOs))
+			for _, utxo := range utxos.UTXOs {
+				selected, err := selectFields(utxo, fields)
+				if err != nil {
+					ctx.JSON(http.StatusInternalServerError, err)
+					return
+				}
+
+				selectedUTXOs = append(selectedUTXOs, selected)
+			}
+
+			ctx.JSON(http.StatusOK, gin.H{
+				"truncated": utxos.Truncated,
+				"total":     utxos.Total,
+				"utxos":     selectedUTXOs,
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, utxos)
+	}
+}
+
+// GetUTXOsByScriptType is a gin handler (factory) to query the value of the
+// wallet UTXOs, split by script type (legacy, segwit, taproot, etc).
+func GetUTXOsByScriptType(s svc.WalletService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		summary, err := s.GetUTXOsByScriptType()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, summary)
+	}
+}
+
+// GetTransactionWalletOutputs is a gin handler (factory) to query the outputs
+// of a transaction that belong to the wallet, with their derivation paths.
+func GetTransactionWalletOutputs(s svc.WalletService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		txHash := ctx.Param("hash")
+
+		outputs, err := s.GetTransactionWalletOutputs(txHash)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, outputs)
+	}
+}
+
+// GetBlockWalletOutputs is a gin handler (factory) to query the outputs of
+// the transactions of a block that belong to the wallet, with their
+// derivation paths.
+func GetBlockWalletOutputs(s svc.WalletService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		blockRef := ctx.Param("block")
+
+		outputs, err := s.GetBlockWalletOutputs(blockRef)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, outputs)
+	}
+}
+
+// SyncAccount is a gin handler (factory) returning, in one call, everything
+// required to synchronize an account since the given block. The last_block
+// field of the response must be used as since_block of the next call.
+//
+// A since_block more than the maximum block range below the chain tip is
+// rejected with 400 Bad Request.
+func SyncAccount(s svc.WalletService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request struct {
+			Descriptors []string `json:"descriptors" binding:"required"`
+			SinceBlock  *string  `json:"since_block"`
+		}
+
+		if err := ctx.BindJSON(&request); err != nil {
+			log.Error("Failed to bind JSON request")
+			ctx.JSON(http.StatusBadRequest, err)
+			return
+		}
+
+		sync, err := s.SyncAccount(request.Descriptors, request.SinceBlock)
+		switch {
+		case errors.Is(err, bus.ErrRangeTooLarge):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			log.WithField("error", err).Error("Failed to sync account")
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, sync)
+	}
+}
+
+// StreamAccountSync is a gin handler (factory) streaming the transactions of
+// an account confirmed since the given block, as newline-delimited JSON
+// events. The last_block field of the final event must be used as
+// since_block of the next call. The since_block is bounded as in
+// SyncAccount.
+func StreamAccountSync(s svc.WalletService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request struct {
+			Descriptors []string `json:"descriptors" binding:"required"`
+			SinceBlock  *string  `json:"since_block"`
+		}
+
+		if err := ctx.BindJSON(&request); err != nil {
+			log.Error("Failed to bind JSON request")
+			ctx.JSON(http.StatusBadRequest, err)
+			return
+		}
+
+		events, err := s.StreamAccountSync(
+			request.Descriptors, request.SinceBlock, ctx.Request.Context().Done())
+		switch {
+		case errors.Is(err, bus.ErrRangeTooLarge):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			log.WithField("error", err).Error("Failed to stream account sync")
+			ctx.JSON(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.Header("Content-Type", "application/x-ndjson")
+		ctx.Stream(func(w io.Writer) bool {
+			event, ok := <-events
+			if !ok {
+				return false
+			}
+
+			if err := json.NewEncoder(w).Encode(event); err != nil {
+				log.WithField("error", err).Error("Failed to write sync event")
+				return false
+			}
+
+			return true
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/httpd/svc"
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubWalletService implements the methods of svc.WalletService and
+// svc.AddressesService exercised by the tests. Calling any other method
+// panics.
+type stubWalletService struct {
+	svc.WalletService
+	svc.AddressesService
+
+	err error
+}
+
+func (s *stubWalletService) SyncAccount([]string, *string) (*types.AccountSync, error) {
+	return &types.AccountSync{}, s.err
+}
+
+func (s *stubWalletService) StreamAccountSync([]string, *string, <-chan struct{}) (<-chan types.SyncEvent, error) {
+	events := make(chan types.SyncEvent)
+	close(events)
+	return events, s.err
+}
+
+func (s *stubWalletService) GetAddresses([]string, *string, svc.AddressesOptions) (types.Addresses, error) {
+	return types.Addresses{}, s.err
+}
+
+// TestSinceBlockRange checks that the endpoints listing transactions since
+// a block reject a block too far below the chain tip as a bad request.
+func TestSinceBlockRange(t *testing.T) {
+	rangeErr := fmt.Errorf("%w: 3000 blocks, maximum is 2016", bus.ErrRangeTooLarge)
+	body := `{"descriptors": ["wpkh(xpub)"], "since_block": "00"}`
+
+	tests := []struct {
+		name       string
+		handler    func(*stubWalletService) gin.HandlerFunc
+		request    *http.Request
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "sync within range",
+			handler:    func(s *stubWalletService) gin.HandlerFunc { return SyncAccount(s) },
+			request:    httptest.NewRequest("POST", "/", strings.NewReader(body)),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "sync above range",
+			handler:    func(s *stubWalletService) gin.HandlerFunc { return SyncAccount(s) },
+			request:    httptest.NewRequest("POST", "/", strings.NewReader(body)),
+			err:        rangeErr,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "sync failure",
+			handler:    func(s *stubWalletService) gin.HandlerFunc { return SyncAccount(s) },
+			request:    httptest.NewRequest("POST", "/", strings.NewReader(body)),
+			err:        errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "stream above range",
+			handler:    func(s *stubWalletService) gin.HandlerFunc { return StreamAccountSync(s) },
+			request:    httptest.NewRequest("POST", "/", strings.NewReader(body)),
+			err:        rangeErr,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "addresses above range",
+			handler:    func(s *stubWalletService) gin.HandlerFunc { return GetAddresses(s) },
+			request:    httptest.NewRequest("GET", "/?block_hash=00", nil),
+			err:        rangeErr,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = tt.request
+
+			tt.handler(&stubWalletService{err: tt.err})(ctx)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus == http.StatusBadRequest && !strings.Contains(recorder.Body.String(), rangeErr.Error()) {
+				t.Errorf("body = %s, want the range error", recorder.Body.String())
+			}
+		})
+	}
+}
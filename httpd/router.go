@@ -9,6 +9,7 @@ import (
 
 func GetRouter(s *svc.Service) *gin.Engine {
 	engine := gin.Default()
+	engine.Use(Gzip())
 
 	engine.GET("timestamp", handlers.GetTimestamp())
 
@@ -18,6 +19,7 @@ func GetRouter(s *svc.Service) *gin.Engine {
 	{
 		controlRouter.GET("descriptors/import", handlers.ImportAccounts(s))
 		controlRouter.POST("descriptors/has", handlers.HasDescriptor(s))
+		controlRouter.GET("descriptors/list", handlers.ListDescriptors(s))
 	}
 
 	// We support both Ledger Blockchain Explorer v2 and v3. The version here
@@ -25,28 +27,65 @@ func GetRouter(s *svc.Service) *gin.Engine {
 	baseRouter := engine.Group("blockchain/:version")
 	{
 		baseRouter.GET("explorer/_health", handlers.GetHealth(s))
+		baseRouter.GET("explorer/_health/deep", handlers.GetDeepHealth(s))
+		baseRouter.GET("explorer/_ready", handlers.GetReadiness(s))
 		baseRouter.GET("explorer/status", handlers.GetStatus(s))
+		baseRouter.GET("explorer/validation", handlers.GetValidationState(s))
+		baseRouter.GET("explorer/policy", handlers.GetRelayPolicy(s))
+		baseRouter.GET("explorer/ibd", handlers.GetIBDEstimate(s))
 	}
 
 	currencyRouter := baseRouter.Group(s.Bus.Currency)
 	{
 		currencyRouter.GET("fees", handlers.GetFees(s))
+		currencyRouter.GET("fees/rates", handlers.GetFeeRates(s))
+		currencyRouter.GET("fees/eviction", handlers.GetEvictionFee(s))
+		currencyRouter.GET("block_intervals", handlers.GetBlockIntervals(s))
 	}
 
 	blocksRouter := currencyRouter.Group("/blocks")
 	{
 		blocksRouter.GET(":block", handlers.GetBlock(s))
+		blocksRouter.GET(":block/delta", handlers.GetBlockDelta(s))
+		blocksRouter.GET(":block/fees", handlers.GetBlockFees(s))
+		blocksRouter.GET(":block/main_chain", handlers.GetBlockChainMembership(s))
+		blocksRouter.POST("locate", handlers.FindLastCommonBlock(s))
 	}
 
 	transactionsRouter := currencyRouter.Group("/transactions")
 	{
+		transactionsRouter.GET(":hash", handlers.GetTransaction(s))
 		transactionsRouter.GET(":hash/hex", handlers.GetTransactionHex(s))
+		transactionsRouter.GET(":hash/canonical", handlers.GetCanonicalTransaction(s))
+		transactionsRouter.GET(":hash/flow", handlers.GetTransactionFlow(s))
+		transactionsRouter.GET(":hash/confirmation_delay", handlers.GetConfirmationDelay(s))
+		transactionsRouter.GET(":hash/depth/:depth", handlers.GetBlockAtDepth(s))
+		transactionsRouter.GET(":hash/replaceability", handlers.GetReplaceability(s))
+		transactionsRouter.GET(":hash/mempool_rank", handlers.GetMempoolRank(s))
+		transactionsRouter.GET(":hash/descendants", handlers.GetMempoolDescendants(s))
+		transactionsRouter.GET(":hash/finality", handlers.GetTransactionFinality(s))
+		transactionsRouter.GET(":hash/weights", handlers.GetInputWeights(s))
+		transactionsRouter.GET(":hash/sighashes", handlers.GetSigHashTypes(s))
+		transactionsRouter.GET(":hash/summary", handlers.GetTransactionSummary(s))
 		transactionsRouter.POST("send", handlers.SendTransaction(s))
+		transactionsRouter.POST("test", handlers.TestMempoolAccept(s))
 	}
 
 	addressesRouter := currencyRouter.Group("/addresses")
 	{
 		addressesRouter.GET(":addresses/transactions", handlers.GetAddresses(s))
+		addressesRouter.GET(":addresses/history", handlers.GetElectrumHistory(s))
+	}
+
+	walletRouter := currencyRouter.Group("/wallet")
+	{
+		walletRouter.GET("balances", handlers.GetBalances(s))
+		walletRouter.GET("utxos", handlers.ListUTXOs(s))
+		walletRouter.GET("utxos/script_types", handlers.GetUTXOsByScriptType(s))
+		walletRouter.GET("outputs/transaction/:hash", handlers.GetTransactionWalletOutputs(s))
+		walletRouter.GET("outputs/block/:block", handlers.GetBlockWalletOutputs(s))
+		walletRouter.POST("sync", handlers.SyncAccount(s))
+		walletRouter.POST("sync/stream", handlers.StreamAccountSync(s))
 	}
 
 	return engine
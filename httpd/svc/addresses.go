@@ -1,21 +1,37 @@
 package svc
 
 import (
+	"math"
+	"sort"
+
+	"github.com/ledgerhq/satstack/bus"
 	"github.com/ledgerhq/satstack/types"
 	"github.com/ledgerhq/satstack/utils"
 
 	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func (s *Service) GetAddresses(addresses []string, blockHash *string) (types.Addresses, error) {
-	// Cache the results of GetTransaction calls against the TxID. The avoids
-	// wasteful querying of the Bitcoin node for the same TxID, within the
-	// lifecycle of this function invocation.
-	s.Bus.NewCache()
-	defer s.Bus.FlushCache()
+// AddressesOptions holds the optional filters and analyses of GetAddresses.
+type AddressesOptions struct {
+	// Reuse enables the address reuse analysis of the transactions.
+	Reuse bool
+
+	// MinValue and MaxValue bound the net effect of the transactions on the
+	// queried addresses, in satoshis. The net effect is negative for
+	// transactions spending from the addresses.
+	MinValue *btcutil.Amount
+	MaxValue *btcutil.Amount
+}
 
+// GetAddresses is a service method to get the transactions involving the
+// given addresses, since the given block (or all, if nil).
+//
+// It returns bus.ErrRangeTooLarge if the block is too far below the chain
+// tip, rather than an empty history.
+func (s *Service) GetAddresses(addresses []string, blockHash *string, opts AddressesOptions) (types.Addresses, error) {
 	blockchainInfo, err := s.Bus.GetBlockChainInfo()
 	if err != nil {
 		return types.Addresses{}, err
@@ -23,24 +39,61 @@ func (s *Service) GetAddresses(addresses []string, blockHash *string) (types.Add
 
 	txResults, err := s.Bus.ListTransactions(blockHash)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"error":     err,
-			"blockHash": nil,
-		}).Error("Unable to fetch transaction")
+		return types.Addresses{}, err
+	}
+
+	// Cache the results of GetTransaction calls against the TxID. The avoids
+	// wasteful querying of the Bitcoin node for the same TxID, within the
+	// lifecycle of this function invocation.
+	txCache := s.Bus.NewTxCache()
+
+	txs := s.buildAddressTransactions(txCache, addresses, txResults, blockchainInfo.Headers)
+
+	if opts.Reuse {
+		var previous []string
+		if blockHash != nil {
+			// Reuse is relative to the whole history of the wallet,
+			// including the transactions before blockHash.
+			history, err := s.Bus.ListTransactions(nil)
+			if err != nil {
+				return types.Addresses{}, err
+			}
+
+			previous = previousOutputAddresses(history, txResults)
+		}
+
+		annotateAddressReuse(txs, previous)
 	}
-	walletTxs := s.filterTransactionsByAddresses(addresses, txResults, blockchainInfo.Headers)
+
+	if opts.MinValue != nil || opts.MaxValue != nil {
+		txs = filterByNetValue(txs, addresses, opts.MinValue, opts.MaxValue)
+	}
+
+	return types.Addresses{
+		Truncated:    false,
+		Transactions: txs,
+	}, nil
+}
+
+// buildAddressTransactions returns the fully resolved transactions of the
+// wallet involving the given addresses, among the passed wallet transaction
+// results. Transactions are fetched through the given cache.
+func (s *Service) buildAddressTransactions(
+	txCache *bus.TxCache, addresses []string, txResults []btcjson.ListTransactionsResult, bestBlockHeight int32,
+) []types.Transaction {
+	walletTxs := s.filterTransactionsByAddresses(txCache, addresses, txResults, bestBlockHeight)
 
 	txs := []types.Transaction{}
 	for _, txn := range walletTxs {
 		block := blockFromTxResult(txn)
-		tx, err := s.GetTransaction(txn.TxID, block, blockchainInfo.Headers)
+		tx, err := s.getTransaction(txCache, txn.TxID, block, bestBlockHeight)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err,
 				"hash":  txn.TxID,
 			}).Error("Unable to fetch transaction")
 
-			s.Bus.Cache.Delete(txn.TxID)
+			txCache.Delete(txn.TxID)
 			continue
 		}
 
@@ -51,51 +104,330 @@ func (s *Service) GetAddresses(addresses []string, blockHash *string) (types.Add
 		}
 	}
 
-	return types.Addresses{
-		Truncated:    false,
-		Transactions: txs,
-	}, nil
+	annotateSpentBy(txs)
+
+	return txs
 }
 
-func (s *Service) filterTransactionsByAddresses(
-	addresses []string, txs []btcjson.ListTransactionsResult, bestBlockHeight int32,
-) []btcjson.ListTransactionsResult {
-	var result []btcjson.ListTransactionsResult
-	var visited []string
+// annotateSpentBy marks the outputs of the transactions that are spent by
+// one of the inputs of the same set of transactions, with the hash of the
+// spending transaction and the index of the spending input.
+//
+// Since the history of an address includes the transactions spending from
+// it, this is sufficient to resolve the outputs paying to the address.
+func annotateSpentBy(txs []types.Transaction) {
+	type spender struct {
+		hash       string
+		inputIndex *int
+	}
+
+	spentBy := make(map[types.OutputIdentifier]spender)
 
 	for _, tx := range txs {
-		if tx.Category == "send" {
-			block := blockFromTxResult(tx)
-			tx2, err := s.GetTransaction(tx.TxID, block, bestBlockHeight)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"error":    err,
-					"hash":     tx.TxID,
-					"category": tx.Category,
-				}).Error("Failed to get wallet transaction")
+		for _, input := range tx.Inputs {
+			if len(input.Coinbase) > 0 || input.OutputIndex == nil {
+				continue
+			}
+
+			outpoint := types.OutputIdentifier{
+				Hash:  input.OutputHash,
+				Index: *input.OutputIndex,
+			}
+
+			spentBy[outpoint] = spender{
+				hash:       tx.Hash,
+				inputIndex: input.InputIndex,
+			}
+		}
+	}
 
-				// abandon processing the current transaction
+	for _, tx := range txs {
+		for idx, output := range tx.Outputs {
+			if output.OutputIndex == nil {
 				continue
 			}
 
-			for _, inputAddress := range getTransactionInputAddresses(*tx2) {
-				if utils.Contains(addresses, inputAddress) && !utils.Contains(visited, tx.TxID) {
-					result = append(result, tx)
-					visited = append(visited, tx.TxID)
-					break
-				}
+			outpoint := types.OutputIdentifier{
+				Hash:  tx.Hash,
+				Index: *output.OutputIndex,
+			}
+
+			spender, ok := spentBy[outpoint]
+			if !ok {
+				continue
+			}
+
+			tx.Outputs[idx].SpentByTxid = spender.hash
+			tx.Outputs[idx].SpentByInputIndex = spender.inputIndex
+		}
+	}
+}
+
+// filterByNetValue returns the transactions whose net effect on the given
+// addresses is within [minValue, maxValue]. A nil bound is ignored.
+//
+// The net effect is the value of the outputs paying to the addresses, minus
+// the value of the inputs spending from them.
+func filterByNetValue(
+	txs []types.Transaction, addresses []string, minValue *btcutil.Amount, maxValue *btcutil.Amount,
+) []types.Transaction {
+	result := []types.Transaction{}
+	for _, tx := range txs {
+		var net btcutil.Amount
+
+		for _, output := range tx.Outputs {
+			if output.Value != nil && utils.Contains(addresses, output.Address) {
+				net += *output.Value
+			}
+		}
+
+		for _, input := range tx.Inputs {
+			if input.Value != nil && utils.Contains(addresses, input.Address) {
+				net -= *input.Value
 			}
 		}
 
-		if utils.Contains(addresses, tx.Address) && !utils.Contains(visited, tx.TxID) {
+		if (minValue != nil && net < *minValue) || (maxValue != nil && net > *maxValue) {
+			continue
+		}
+
+		result = append(result, tx)
+	}
+
+	return result
+}
+
+// previousOutputAddresses returns the addresses paid by the wallet
+// transactions of the history that are not among the listed ones, i.e., the
+// transactions preceding the listing.
+//
+// Only the outputs known to the wallet are reported by the node: those paying
+// to the wallet, and the destinations of its outgoing transactions.
+func previousOutputAddresses(history []btcjson.ListTransactionsResult, listed []btcjson.ListTransactionsResult) []string {
+	listedTxs := make(map[string]struct{}, len(listed))
+	for _, tx := range listed {
+		listedTxs[tx.TxID] = struct{}{}
+	}
+
+	var addresses []string
+	for _, tx := range history {
+		if _, ok := listedTxs[tx.TxID]; ok || tx.Address == "" {
+			continue
+		}
+
+		if !utils.Contains(addresses, tx.Address) {
+			addresses = append(addresses, tx.Address)
+		}
+	}
+
+	return addresses
+}
+
+// annotateAddressReuse flags the transactions whose outputs reuse an
+// address, within the transaction, relative to the earlier transactions of
+// the passed set, or to the previous addresses of the wallet.
+//
+// Transactions are processed in chain order, i.e., by increasing block
+// height, followed by the unconfirmed ones.
+func annotateAddressReuse(txs []types.Transaction, previous []string) {
+	order := make([]int, len(txs))
+	for i := range order {
+		order[i] = i
+	}
+
+	height := func(tx types.Transaction) int64 {
+		if tx.Block == nil || tx.Block.Height < 0 {
+			return math.MaxInt64
+		}
+
+		return tx.Block.Height
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return height(txs[order[i]]) < height(txs[order[j]])
+	})
+
+	seen := make(map[string]struct{}, len(previous))
+	for _, address := range previous {
+		seen[address] = struct{}{}
+	}
+
+	for _, idx := range order {
+		tx := &txs[idx]
+
+		inputs := make(map[string]struct{}, len(tx.Inputs))
+		for _, input := range tx.Inputs {
+			inputs[input.Address] = struct{}{}
+		}
+
+		reuse := types.AddressReuse{}
+		outputs := make(map[string]struct{}, len(tx.Outputs))
+		for _, output := range tx.Outputs {
+			if output.Address == "" {
+				continue
+			}
+
+			_, inSeen := seen[output.Address]
+			_, inInputs := inputs[output.Address]
+			_, inOutputs := outputs[output.Address]
+
+			if (inSeen || inInputs || inOutputs) && !utils.Contains(reuse.Addresses, output.Address) {
+				reuse.Addresses = append(reuse.Addresses, output.Address)
+			}
+
+			outputs[output.Address] = struct{}{}
+		}
+
+		for address := range outputs {
+			seen[address] = struct{}{}
+		}
+
+		reuse.Reused = len(reuse.Addresses) > 0
+		tx.AddressReuse = &reuse
+	}
+}
+
+// GetElectrumHistory returns the history of an address, using the height
+// conventions of the Electrum protocol.
+//
+// Confirmed transactions are listed first, in the order of increasing block
+// height, followed by mempool transactions.
+func (s *Service) GetElectrumHistory(address string) ([]types.ElectrumHistoryItem, error) {
+	addresses, err := s.GetAddresses([]string{address}, nil, AddressesOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	history := []types.ElectrumHistoryItem{}
+	for _, tx := range addresses.Transactions {
+		if tx.Block != nil && tx.Block.Height > 0 {
+			history = append(history, types.ElectrumHistoryItem{
+				TxHash: tx.Hash,
+				Height: tx.Block.Height,
+			})
+			continue
+		}
+
+		entry, err := s.Bus.GetMempoolEntry(tx.Hash)
+		if err != nil {
+			// The transaction is neither confirmed, nor in the mempool.
+			// This is typically the case of conflicted transactions.
+			log.WithFields(log.Fields{
+				"error": err,
+				"hash":  tx.Hash,
+			}).Debug("Skipping transaction not found in mempool")
+			continue
+		}
+
+		fee := s.Bus.MempoolEntryFee(entry)
+		history = append(history, types.ElectrumHistoryItem{
+			TxHash: tx.Hash,
+			Height: electrumMempoolHeight(entry.Depends),
+			Fee:    &fee,
+		})
+	}
+
+	sortElectrumHistory(history)
+
+	return history, nil
+}
+
+// electrumMempoolHeight returns the Electrum height of a mempool transaction
+// with the given in-mempool parents. Height -1 indicates that the transaction
+// has unconfirmed parents, and 0 that all of its inputs are confirmed.
+func electrumMempoolHeight(depends []string) int64 {
+	if len(depends) > 0 {
+		return -1
+	}
+
+	return 0
+}
+
+// sortElectrumHistory sorts the history items in place, by increasing block
+// height, followed by mempool transactions. The relative order of mempool
+// transactions is preserved.
+func sortElectrumHistory(history []types.ElectrumHistoryItem) {
+	sort.SliceStable(history, func(i, j int) bool {
+		return electrumSortKey(history[i].Height) < electrumSortKey(history[j].Height)
+	})
+}
+
+// electrumSortKey maps the Electrum height of a history item to a key, so
+// that mempool transactions are sorted after the confirmed ones.
+func electrumSortKey(height int64) int64 {
+	if height <= 0 {
+		return math.MaxInt64
+	}
+
+	return height
+}
+
+// filterTransactionsByAddresses returns the wallet transaction results
+// involving the given addresses, de-duplicated by txid.
+//
+// A transaction may be listed several times, for ex: once per output, or
+// while it transitions from the mempool to a block. In this case, the
+// confirmed record is preferred.
+func (s *Service) filterTransactionsByAddresses(
+	txCache *bus.TxCache, addresses []string, txs []btcjson.ListTransactionsResult, bestBlockHeight int32,
+) []btcjson.ListTransactionsResult {
+	var result []btcjson.ListTransactionsResult
+	visited := make(map[string]int)
+
+	add := func(tx btcjson.ListTransactionsResult) {
+		idx, ok := visited[tx.TxID]
+		if !ok {
+			visited[tx.TxID] = len(result)
 			result = append(result, tx)
-			visited = append(visited, tx.TxID)
+			return
+		}
+
+		if result[idx].BlockHash == "" && tx.BlockHash != "" {
+			result[idx] = tx
+		}
+	}
+
+	for _, tx := range txs {
+		if s.involvesAddresses(txCache, addresses, tx, bestBlockHeight) {
+			add(tx)
 		}
 	}
 
 	return result
 }
 
+// involvesAddresses checks if a wallet transaction result pays to one of the
+// given addresses, or for outgoing transactions, spends from one of them.
+//
+// The inputs of outgoing transactions are resolved through the given cache,
+// which should be shared by the caller, since this is expensive.
+func (s *Service) involvesAddresses(
+	txCache *bus.TxCache, addresses []string, tx btcjson.ListTransactionsResult, bestBlockHeight int32,
+) bool {
+	if tx.Category == "send" {
+		block := blockFromTxResult(tx)
+		tx2, err := s.getTransaction(txCache, tx.TxID, block, bestBlockHeight)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":    err,
+				"hash":     tx.TxID,
+				"category": tx.Category,
+			}).Error("Failed to get wallet transaction")
+
+			// abandon processing the current transaction
+			return false
+		}
+
+		for _, inputAddress := range getTransactionInputAddresses(*tx2) {
+			if utils.Contains(addresses, inputAddress) {
+				return true
+			}
+		}
+	}
+
+	return utils.Contains(addresses, tx.Address)
+}
+
 func getTransactionInputAddresses(tx types.Transaction) []string {
 	var result []string
 
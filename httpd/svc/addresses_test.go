@@ -0,0 +1,423 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestBlockFromTxResult(t *testing.T) {
+	height := int32(680000)
+
+	tests := []struct {
+		name string
+		tx   btcjson.ListTransactionsResult
+		want string
+	}{
+		{
+			name: "confirmed",
+			tx: btcjson.ListTransactionsResult{
+				BlockHash:   "0000000000000000000b4d0b2e8e7c2a3a2e2d6d8a3e8f7a6c5b4a3928171615",
+				BlockHeight: &height,
+				BlockTime:   1619000000,
+			},
+			want: `{"hash":"0000000000000000000b4d0b2e8e7c2a3a2e2d6d8a3e8f7a6c5b4a3928171615","height":680000,"time":"2021-04-21T10:13:20Z"}`,
+		},
+		{
+			// Unconfirmed transactions keep the historical representation
+			// expected by Ledger Live, instead of a null block.
+			name: "unconfirmed",
+			tx:   btcjson.ListTransactionsResult{},
+			want: `{"hash":"","height":-1,"time":"1970-01-01T00:00:00Z"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(blockFromTxResult(tt.tx))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("blockFromTxResult() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvolvesAddresses(t *testing.T) {
+	addresses := []string{"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"}
+
+	tests := []struct {
+		name string
+		tx   btcjson.ListTransactionsResult
+		want bool
+	}{
+		{
+			name: "received on account address",
+			tx:   btcjson.ListTransactionsResult{Category: "receive", Address: addresses[0]},
+			want: true,
+		},
+		{
+			name: "received on other address",
+			tx:   btcjson.ListTransactionsResult{Category: "receive", Address: "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"},
+			want: false,
+		},
+		{
+			name: "immature coinbase on account address",
+			tx:   btcjson.ListTransactionsResult{Category: "immature", Address: addresses[0]},
+			want: true,
+		},
+	}
+
+	s := &Service{}
+	for _, tt := range tests {
+		if got := s.involvesAddresses(nil, addresses, tt.tx, 680000); got != tt.want {
+			t.Errorf("involvesAddresses(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterTransactionsByAddresses(t *testing.T) {
+	const (
+		address   = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+		txid      = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+		blockHash = "00000000000000000002a7c4c1e48d76c5a37902165a270156b7a8d72728a054"
+	)
+
+	unconfirmed := btcjson.ListTransactionsResult{Category: "receive", Address: address, TxID: txid}
+	confirmed := btcjson.ListTransactionsResult{
+		Category: "receive", Address: address, TxID: txid, BlockHash: blockHash, Confirmations: 1,
+	}
+
+	tests := []struct {
+		name string
+		txs  []btcjson.ListTransactionsResult
+	}{
+		{"unconfirmed first", []btcjson.ListTransactionsResult{unconfirmed, confirmed}},
+		{"confirmed first", []btcjson.ListTransactionsResult{confirmed, unconfirmed}},
+	}
+
+	s := &Service{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No "send" categories, so the inputs need no resolution.
+			got := s.filterTransactionsByAddresses(nil, []string{address}, tt.txs, 680000)
+
+			want := []btcjson.ListTransactionsResult{confirmed}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("filterTransactionsByAddresses() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// reuseTx returns a transaction confirmed at the given height, or
+// unconfirmed if the height is negative, spending from and paying to the
+// given addresses.
+func reuseTx(height int64, inputs []string, outputs []string) types.Transaction {
+	tx := types.Transaction{Block: &types.Block{Height: height}}
+	for _, address := range inputs {
+		tx.Inputs = append(tx.Inputs, types.Input{Address: address})
+	}
+
+	for _, address := range outputs {
+		tx.Outputs = append(tx.Outputs, types.Output{Address: address})
+	}
+
+	return tx
+}
+
+func TestAnnotateAddressReuse(t *testing.T) {
+	const (
+		addrA = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+		addrB = "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"
+		addrC = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	)
+
+	tests := []struct {
+		name     string
+		txs      []types.Transaction
+		previous []string
+		want     []types.AddressReuse
+	}{
+		{
+			name: "fresh addresses",
+			txs: []types.Transaction{
+				reuseTx(100, nil, []string{addrA}),
+				reuseTx(101, nil, []string{addrB}),
+			},
+			want: []types.AddressReuse{{}, {}},
+		},
+		{
+			name: "address paid twice in the same transaction",
+			txs: []types.Transaction{
+				reuseTx(100, nil, []string{addrA, addrB, addrA}),
+			},
+			want: []types.AddressReuse{{Reused: true, Addresses: []string{addrA}}},
+		},
+		{
+			name: "change sent back to an input address",
+			txs: []types.Transaction{
+				reuseTx(100, []string{addrA}, []string{addrB, addrA}),
+			},
+			want: []types.AddressReuse{{Reused: true, Addresses: []string{addrA}}},
+		},
+		{
+			name: "outputs without an address are ignored",
+			txs: []types.Transaction{
+				reuseTx(100, nil, []string{"", addrA, ""}),
+			},
+			want: []types.AddressReuse{{}},
+		},
+		{
+			name: "only later transactions in chain order are flagged",
+			txs: []types.Transaction{
+				reuseTx(105, nil, []string{addrA, addrC}),
+				reuseTx(100, nil, []string{addrA}),
+			},
+			want: []types.AddressReuse{
+				{Reused: true, Addresses: []string{addrA}},
+				{},
+			},
+		},
+		{
+			name: "unconfirmed transactions come after confirmed ones",
+			txs: []types.Transaction{
+				reuseTx(-1, nil, []string{addrB}),
+				reuseTx(100, nil, []string{addrB}),
+			},
+			want: []types.AddressReuse{
+				{Reused: true, Addresses: []string{addrB}},
+				{},
+			},
+		},
+		{
+			name: "address paid before the listed transactions",
+			txs: []types.Transaction{
+				reuseTx(100, nil, []string{addrA, addrB}),
+			},
+			previous: []string{addrB},
+			want:     []types.AddressReuse{{Reused: true, Addresses: []string{addrB}}},
+		},
+		{
+			name: "unknown block is treated as unconfirmed",
+			txs: []types.Transaction{
+				{Outputs: []types.Output{{Address: addrC}}},
+				reuseTx(100, nil, []string{addrC}),
+			},
+			want: []types.AddressReuse{
+				{Reused: true, Addresses: []string{addrC}},
+				{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotateAddressReuse(tt.txs, tt.previous)
+
+			for i, tx := range tt.txs {
+				if tx.AddressReuse == nil {
+					t.Fatalf("annotateAddressReuse() left transaction %d unannotated", i)
+				}
+
+				if !reflect.DeepEqual(*tx.AddressReuse, tt.want[i]) {
+					t.Errorf("annotateAddressReuse() transaction %d = %+v, want %+v", i, *tx.AddressReuse, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPreviousOutputAddresses(t *testing.T) {
+	const (
+		addrA = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+		addrB = "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"
+		addrC = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	)
+
+	listed := []btcjson.ListTransactionsResult{
+		{TxID: "c", Category: "receive", Address: addrC},
+	}
+
+	history := []btcjson.ListTransactionsResult{
+		{TxID: "a", Category: "receive", Address: addrA},
+		{TxID: "b", Category: "send", Address: addrB},
+		{TxID: "b", Category: "receive", Address: addrA},
+		{TxID: "d", Category: "send"}, // no address, for ex: OP_RETURN
+		{TxID: "c", Category: "receive", Address: addrC},
+	}
+
+	want := []string{addrA, addrB}
+	if got := previousOutputAddresses(history, listed); !reflect.DeepEqual(got, want) {
+		t.Errorf("previousOutputAddresses() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterByNetValue(t *testing.T) {
+	const (
+		account = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+		other   = "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"
+	)
+
+	amount := func(value btcutil.Amount) *btcutil.Amount {
+		return &value
+	}
+
+	// Net effects on the account: +50000, +1000000, -30000 and 0.
+	txs := []types.Transaction{
+		{
+			Hash:    "received",
+			Outputs: []types.Output{{Address: account, Value: amount(50000)}},
+		},
+		{
+			Hash: "large payment",
+			Outputs: []types.Output{
+				{Address: account, Value: amount(600000)},
+				{Address: account, Value: amount(400000)},
+			},
+		},
+		{
+			Hash:    "sent with change",
+			Inputs:  []types.Input{{Address: account, Value: amount(100000)}},
+			Outputs: []types.Output{{Address: other, Value: amount(29000)}, {Address: account, Value: amount(70000)}},
+		},
+		{
+			Hash:    "unrelated",
+			Outputs: []types.Output{{Address: other, Value: amount(50000)}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		minValue *btcutil.Amount
+		maxValue *btcutil.Amount
+		want     []string
+	}{
+		{"no bounds", nil, nil, []string{"received", "large payment", "sent with change", "unrelated"}},
+		{"minimum only", amount(50000), nil, []string{"received", "large payment"}},
+		{"maximum only", nil, amount(0), []string{"sent with change", "unrelated"}},
+		{"inclusive range", amount(50000), amount(1000000), []string{"received", "large payment"}},
+		{"outgoing range", amount(-30000), amount(-1), []string{"sent with change"}},
+		{"empty range", amount(60000), amount(999999), []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, tx := range filterByNetValue(txs, []string{account}, tt.minValue, tt.maxValue) {
+				got = append(got, tx.Hash)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterByNetValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAddressesRangeTooLarge(t *testing.T) {
+	const sinceHeight = fakeNodeTip - 2017
+
+	s, calls := newFakeNodeService(t, func(method string, _ []json.RawMessage) (interface{}, *btcjson.RPCError, bool) {
+		switch method {
+		case "getblockheader":
+			return map[string]interface{}{"height": sinceHeight}, nil, true
+		case "getblockcount":
+			return fakeNodeTip, nil, true
+		case "listsinceblock":
+			t.Errorf("listsinceblock sent for a block beyond the maximum range")
+		}
+
+		return nil, nil, false
+	})
+
+	blockHash := "0000000000000000000b4d0b2e8e7c2a3a2e2d6d8a3e8f7a6c5b4a3928171615"
+	addresses, err := s.GetAddresses([]string{"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"}, &blockHash, AddressesOptions{})
+	if !errors.Is(err, bus.ErrRangeTooLarge) {
+		t.Fatalf("GetAddresses() error = %v, want %v", err, bus.ErrRangeTooLarge)
+	}
+
+	if addresses.Transactions != nil {
+		t.Errorf("GetAddresses() transactions = %v, want none", addresses.Transactions)
+	}
+
+	if calls["listsinceblock"] != 0 {
+		t.Errorf("listsinceblock sent %d times, want 0", calls["listsinceblock"])
+	}
+}
+
+func TestElectrumMempoolHeight(t *testing.T) {
+	tests := []struct {
+		name    string
+		depends []string
+		want    int64
+	}{
+		{"confirmed parents", nil, 0},
+		{"unconfirmed parent", []string{"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := electrumMempoolHeight(tt.depends); got != tt.want {
+				t.Errorf("electrumMempoolHeight() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortElectrumHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []types.ElectrumHistoryItem
+		want    []types.ElectrumHistoryItem
+	}{
+		{
+			name: "confirmed by height",
+			history: []types.ElectrumHistoryItem{
+				{TxHash: "b", Height: 700001},
+				{TxHash: "a", Height: 700000},
+			},
+			want: []types.ElectrumHistoryItem{
+				{TxHash: "a", Height: 700000},
+				{TxHash: "b", Height: 700001},
+			},
+		},
+		{
+			name: "mempool after confirmed, in original order",
+			history: []types.ElectrumHistoryItem{
+				{TxHash: "c", Height: -1},
+				{TxHash: "d", Height: 0},
+				{TxHash: "a", Height: 700000},
+			},
+			want: []types.ElectrumHistoryItem{
+				{TxHash: "a", Height: 700000},
+				{TxHash: "c", Height: -1},
+				{TxHash: "d", Height: 0},
+			},
+		},
+		{
+			name:    "empty",
+			history: []types.ElectrumHistoryItem{},
+			want:    []types.ElectrumHistoryItem{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortElectrumHistory(tt.history)
+			if !reflect.DeepEqual(tt.history, tt.want) {
+				t.Errorf("sortElectrumHistory() = %+v, want %+v", tt.history, tt.want)
+			}
+		})
+	}
+}
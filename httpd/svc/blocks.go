@@ -2,17 +2,22 @@ package svc
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/ledgerhq/satstack/bus"
 	"github.com/ledgerhq/satstack/types"
 	"github.com/ledgerhq/satstack/utils"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
 
-// GetBlock is a service method to get a Block by a string reference
-func (s *Service) GetBlock(ref string) (*types.Block, error) {
+// GetBlock is a service method to get a Block by a string reference.
+//
+// The list of block transactions is filtered according to the coinbase
+// filter.
+func (s *Service) GetBlock(ref string, coinbase types.CoinbaseFilter) (*types.Block, error) {
 	rawBlockHash, err := s.getBlockHashByReference(ref)
 	if err != nil {
 		return nil, err
@@ -23,9 +28,169 @@ func (s *Service) GetBlock(ref string) (*types.Block, error) {
 		return nil, err
 	}
 
+	if block.Transactions != nil {
+		txs := filterCoinbase(*block.Transactions, coinbase)
+		block.Transactions = &txs
+	}
+
+	localizeBlockTime(block, s.Location)
+
 	return block, nil
 }
 
+// filterCoinbase applies a CoinbaseFilter on the list of transaction IDs of
+// a block.
+//
+// By consensus, the coinbase is always the first transaction of a block, so
+// there's no need to decode the transactions.
+func filterCoinbase(txs []string, filter types.CoinbaseFilter) []string {
+	if len(txs) == 0 {
+		return txs
+	}
+
+	switch filter {
+	case types.CoinbaseExclude:
+		return txs[1:]
+	case types.CoinbaseOnly:
+		return txs[:1]
+	default:
+		return txs
+	}
+}
+
+// GetBlockDelta is a service method to get the outpoints spent and created
+// by a block, referenced by a string.
+func (s *Service) GetBlockDelta(ref string) (*types.BlockDelta, error) {
+	rawBlockHash, err := s.getBlockHashByReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Bus.GetBlockDelta(rawBlockHash)
+}
+
+// GetBlockFees is a service method to get the transactions of a block,
+// referenced by a string, along with their fees.
+func (s *Service) GetBlockFees(ref string) (*types.BlockFees, error) {
+	rawBlockHash, err := s.getBlockHashByReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Bus.GetBlockFees(rawBlockHash)
+}
+
+// GetBlockChainMembership is a service method to check whether a block,
+// referenced by a string, is on the main chain.
+//
+// Stale blocks can only be referenced by hash, since heights are always
+// resolved on the main chain.
+func (s *Service) GetBlockChainMembership(ref string) (*types.BlockChainMembership, error) {
+	rawBlockHash, err := s.getBlockHashByReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := s.Bus.GetBlockHeader(rawBlockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.BlockChainMembership{
+		Hash:        header.Hash,
+		Height:      int64(header.Height),
+		InMainChain: bus.InMainChain(header),
+	}, nil
+}
+
+// maxLocatorHashes is the maximum number of block hashes accepted by
+// FindLastCommonBlock. It matches the maximum size of a block locator in
+// Bitcoin Core.
+const maxLocatorHashes = 101
+
+// FindLastCommonBlock is a service method to get the most recent block of
+// the given list, that is on the main chain of the node. The hashes are
+// expected newest first, for ex: the last block headers known by a client.
+func (s *Service) FindLastCommonBlock(hashes []string) (*types.CommonBlock, error) {
+	if len(hashes) > maxLocatorHashes {
+		return nil, fmt.Errorf("%w: %d > %d", ErrTooManyBlockHashes, len(hashes), maxLocatorHashes)
+	}
+
+	chainHashes := make([]*chainhash.Hash, len(hashes))
+	for i, hash := range hashes {
+		chainHash, err := utils.ParseChainHash(hash)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", bus.ErrMalformedChainHash, hash)
+		}
+
+		chainHashes[i] = chainHash
+	}
+
+	common, tip, err := s.Bus.FindLastCommonBlock(chainHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	localizeBlockTime(common, s.Location)
+	localizeBlockTime(tip, s.Location)
+
+	return &types.CommonBlock{Common: common, Tip: tip}, nil
+}
+
+// GetBlockIntervals is a service method to get statistics on the time between
+// the last count blocks and their parent. The count must not exceed the
+// maximum block range of the Bus.
+func (s *Service) GetBlockIntervals(count int64) (*types.BlockIntervals, error) {
+	times, err := s.Bus.GetRecentBlockTimes(count)
+	if err != nil {
+		return nil, err
+	}
+
+	return blockIntervalStats(times), nil
+}
+
+// blockIntervalStats computes statistics on the intervals between the
+// passed block timestamps, sorted by height.
+//
+// Block timestamps are not monotonic, since they only need to be greater
+// than the median time of the previous 11 blocks. Negative intervals are
+// therefore clamped to zero.
+func blockIntervalStats(times []int64) *types.BlockIntervals {
+	if len(times) < 2 {
+		return &types.BlockIntervals{}
+	}
+
+	intervals := make([]int64, 0, len(times)-1)
+	var sum int64
+	for i := 1; i < len(times); i++ {
+		interval := times[i] - times[i-1]
+		if interval < 0 {
+			interval = 0
+		}
+
+		intervals = append(intervals, interval)
+		sum += interval
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i] < intervals[j]
+	})
+
+	n := len(intervals)
+	median := float64(intervals[n/2])
+	if n%2 == 0 {
+		median = float64(intervals[n/2-1]+intervals[n/2]) / 2
+	}
+
+	return &types.BlockIntervals{
+		Blocks:  n,
+		Average: float64(sum) / float64(n),
+		Median:  median,
+		Min:     intervals[0],
+		Max:     intervals[n-1],
+	}
+}
+
 func (s *Service) getBlockHashByReference(ref string) (*chainhash.Hash, error) {
 	switch {
 	case ref == "current":
@@ -41,7 +206,7 @@ func (s *Service) getBlockHashByReference(ref string) (*chainhash.Hash, error) {
 
 			switch err {
 			case nil:
-				return s.Bus.GetBlockHash(blockHeight)
+				return s.getBlockHashByHeight(blockHeight)
 
 			default:
 				return nil, fmt.Errorf("invalid block '%s'", ref)
@@ -50,3 +215,26 @@ func (s *Service) getBlockHashByReference(ref string) (*chainhash.Hash, error) {
 
 	}
 }
+
+// getBlockHashByHeight returns the hash of the block at the given height in
+// the main chain.
+//
+// Bitcoin Core fails with an opaque error if the height is out of range. In
+// this case, a *bus.ErrBlockHeightOutOfRange is returned instead, with the
+// height of the current tip.
+func (s *Service) getBlockHashByHeight(height int64) (*chainhash.Hash, error) {
+	hash, err := s.Bus.GetBlockHash(height)
+	if err == nil {
+		return hash, nil
+	}
+
+	tip, tipErr := s.Bus.GetBlockCount()
+	if tipErr == nil && (height < 0 || height > tip) {
+		return nil, &bus.ErrBlockHeightOutOfRange{
+			Requested: height,
+			Tip:       tip,
+		}
+	}
+
+	return nil, err
+}
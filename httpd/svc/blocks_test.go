@@ -0,0 +1,69 @@
+package svc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+)
+
+func TestBlockIntervalStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		times []int64
+		want  *types.BlockIntervals
+	}{
+		{"no blocks", nil, &types.BlockIntervals{}},
+		{"single block", []int64{1600000000}, &types.BlockIntervals{}},
+		{
+			name:  "odd number of intervals",
+			times: []int64{0, 600, 1500, 1800},
+			want:  &types.BlockIntervals{Blocks: 3, Average: 600, Median: 600, Min: 300, Max: 900},
+		},
+		{
+			name:  "even number of intervals",
+			times: []int64{0, 600, 1500, 1800, 3000},
+			want:  &types.BlockIntervals{Blocks: 4, Average: 750, Median: 750, Min: 300, Max: 1200},
+		},
+		{
+			name:  "negative interval clamped",
+			times: []int64{1000, 900, 1500},
+			want:  &types.BlockIntervals{Blocks: 2, Average: 300, Median: 300, Min: 0, Max: 600},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := blockIntervalStats(tt.times)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("blockIntervalStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterCoinbase(t *testing.T) {
+	txs := []string{"coinbase", "tx1", "tx2"}
+
+	tests := []struct {
+		name   string
+		txs    []string
+		filter types.CoinbaseFilter
+		want   []string
+	}{
+		{"include", txs, types.CoinbaseInclude, []string{"coinbase", "tx1", "tx2"}},
+		{"exclude", txs, types.CoinbaseExclude, []string{"tx1", "tx2"}},
+		{"only", txs, types.CoinbaseOnly, []string{"coinbase"}},
+		{"default includes", txs, "", []string{"coinbase", "tx1", "tx2"}},
+		{"exclude from empty list", []string{}, types.CoinbaseExclude, []string{}},
+		{"only from empty list", []string{}, types.CoinbaseOnly, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterCoinbase(tt.txs, tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterCoinbase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -48,3 +48,7 @@ func (s *Service) HasDescriptor(descriptor string) (bool, error) {
 
 	return true, nil
 }
+
+func (s *Service) ListDescriptors(private bool) ([]bus.WalletDescriptor, error) {
+	return s.Bus.ListDescriptors(private)
+}
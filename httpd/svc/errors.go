@@ -0,0 +1,14 @@
+package svc
+
+import "errors"
+
+var (
+	// ErrUnresolvedPrevout indicates that the output spent by an input of a
+	// transaction could not be resolved, typically because it does not
+	// belong to the wallet, and the transaction index is disabled.
+	ErrUnresolvedPrevout = errors.New("unresolved previous output")
+
+	// ErrTooManyBlockHashes indicates that a client sent more block hashes
+	// than allowed in a single request.
+	ErrTooManyBlockHashes = errors.New("too many block hashes")
+)
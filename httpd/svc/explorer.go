@@ -1,27 +1,104 @@
 package svc
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/protocol"
+	"github.com/ledgerhq/satstack/types"
 	"github.com/ledgerhq/satstack/version"
 	log "github.com/sirupsen/logrus"
 )
 
-func (s *Service) GetHealth() error {
+func (s *Service) GetHealth() (*bus.ExplorerHealth, error) {
 	_, err := s.Bus.GetBlockChainInfo()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// TODO: Check contents of GetBlockChainInfo response
 
-	return nil
+	tipStale, err := s.Bus.IsTipStale()
+	if err != nil {
+		return nil, err
+	}
+
+	if tipStale {
+		log.WithField(
+			"threshold", s.Bus.StaleTipThreshold,
+		).Warn("Chain tip is stale")
+	}
+
+	return &bus.ExplorerHealth{
+		Status:   "OK",
+		TipStale: tipStale,
+	}, nil
+}
+
+// GetDeepHealth checks that SatStack is able to fetch and decode the most
+// recent transaction of the wallet end-to-end, including its previous
+// outputs. Unlike GetHealth, this catches issues like a missing transaction
+// index or an unloaded wallet.
+//
+// The check is reported as skipped if the wallet has no transactions yet,
+// since there is nothing to exercise the transaction pipeline with. This is
+// the normal state of a fresh install, and not a failure.
+func (s *Service) GetDeepHealth() *bus.DeepHealth {
+	start := time.Now()
+	health := bus.DeepHealth{Status: bus.HealthPass}
+
+	err := func() error {
+		blockchainInfo, err := s.Bus.GetBlockChainInfo()
+		if err != nil {
+			return err
+		}
+
+		hash, err := s.Bus.LatestTransactionHash()
+		if err != nil {
+			return err
+		}
+
+		health.TxHash = hash
+
+		_, err = s.GetTransaction(hash, nil, blockchainInfo.Headers)
+		return err
+	}()
+
+	health.DurationMs = time.Since(start).Milliseconds()
+
+	if errors.Is(err, bus.ErrNoWalletTransactions) {
+		health.Status = bus.HealthSkip
+		return &health
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":  err,
+			"txHash": health.TxHash,
+		}).Error("Deep health check failed")
+
+		health.Status = bus.HealthFail
+		health.Error = err.Error()
+	}
+
+	return &health
+}
+
+// GetReadiness checks whether SatStack is ready to serve requests, for use as
+// a readiness probe.
+func (s *Service) GetReadiness() *bus.Readiness {
+	return s.Bus.CheckReadiness()
 }
 
+// GetFees returns the fee rate estimates of the node for the given
+// confirmation targets, in blocks.
+//
+// The estimates are keyed by target, in satoshis per kvB, as expected by the
+// Ledger Live explorer API.
 func (s *Service) GetFees(targets []int64, mode string) map[string]interface{} {
 	result := make(map[string]interface{})
 	for _, target := range targets {
@@ -33,6 +110,49 @@ func (s *Service) GetFees(targets []int64, mode string) map[string]interface{} {
 	return result
 }
 
+// GetFeeRates returns the same estimates as GetFees, in all the units of
+// types.FeeRate. They are served separately, since Ledger Live expects every
+// target of GetFees to map to a number.
+func (s *Service) GetFeeRates(targets []int64, mode string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, target := range targets {
+		fee := s.Bus.EstimateSmartFee(target, mode)
+		result[strconv.FormatInt(target, 10)] = protocol.FeeRateFromKvB(fee)
+	}
+
+	result["last_updated"] = int32(time.Now().Unix())
+	return result
+}
+
+// GetEvictionFee returns the minimum fee rate to enter the mempool of the
+// node, along with whether the mempool is currently full.
+func (s *Service) GetEvictionFee() (types.FeeRate, bool, error) {
+	feeRate, full, err := s.Bus.EvictionFeeRate()
+	if err != nil {
+		return types.FeeRate{}, false, err
+	}
+
+	return protocol.FeeRateFromKvB(feeRate), full, nil
+}
+
+// GetValidationState returns the validation state of the node, including
+// whether it relies on assumevalid.
+func (s *Service) GetValidationState() (*bus.ValidationState, error) {
+	return s.Bus.GetValidationState()
+}
+
+// GetRelayPolicy returns the relay policy of the node, such as the minimum
+// relay fee and standardness rules.
+func (s *Service) GetRelayPolicy() (*bus.RelayPolicy, error) {
+	return s.Bus.GetRelayPolicy()
+}
+
+// GetIBDEstimate returns the estimated time remaining until the node
+// completes its initial block download.
+func (s *Service) GetIBDEstimate() (*bus.IBDEstimate, error) {
+	return s.Bus.GetIBDEstimate()
+}
+
 func (s *Service) GetStatus() *bus.ExplorerStatus {
 	// Prepare base bus.ExplorerStatus instance.
 	status := bus.ExplorerStatus{
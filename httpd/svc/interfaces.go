@@ -1,6 +1,7 @@
 package svc
 
 import (
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/ledgerhq/satstack/bus"
 	"github.com/ledgerhq/satstack/config"
 	"github.com/ledgerhq/satstack/types"
@@ -8,27 +9,64 @@ import (
 
 type TransactionsService interface {
 	GetTransaction(hash string, block *types.Block, bestBlockHeight int32) (*types.Transaction, error)
+	GetTransactionAtTip(hash string, pinnedTip *chainhash.Hash, verbose bool) (*types.Transaction, error)
 	GetTransactionHex(hash string) (string, error)
-	SendTransaction(tx string) (string, error)
+	GetCanonicalTransaction(hash string) (*types.CanonicalTransaction, error)
+	SendTransaction(tx string) (*bus.BroadcastResult, error)
+	TestMempoolAccept(txs []string) ([]bus.MempoolAcceptResult, error)
+	GetConfirmationDelay(hash string) (int64, error)
+	GetBlockAtDepth(hash string, depth int64) (*types.Block, error)
+	GetReplaceability(hash string) (*types.Replaceability, error)
+	GetMempoolRank(hash string) (*types.MempoolRank, error)
+	GetMempoolDescendants(hash string) (*types.MempoolDescendants, error)
+	GetTransactionFinality(hash string) (*types.Finality, error)
+	GetTransactionFlow(hash string) (*types.TransactionFlow, error)
+	GetInputWeights(hash string) ([]types.InputWeight, error)
+	GetSigHashTypes(hash string) ([]types.InputSigHashes, error)
+	GetTransactionSummary(hash string) (*types.TransactionSummary, error)
 }
 
 type BlocksService interface {
-	GetBlock(ref string) (*types.Block, error)
+	GetBlock(ref string, coinbase types.CoinbaseFilter) (*types.Block, error)
+	GetBlockDelta(ref string) (*types.BlockDelta, error)
+	GetBlockFees(ref string) (*types.BlockFees, error)
+	GetBlockIntervals(count int64) (*types.BlockIntervals, error)
+	GetBlockChainMembership(ref string) (*types.BlockChainMembership, error)
+	FindLastCommonBlock(hashes []string) (*types.CommonBlock, error)
 }
 
 type AddressesService interface {
-	GetAddresses(addresses []string, blockHash *string) (types.Addresses, error)
+	GetAddresses(addresses []string, blockHash *string, opts AddressesOptions) (types.Addresses, error)
+	GetElectrumHistory(address string) ([]types.ElectrumHistoryItem, error)
 }
 
 type ExplorerService interface {
-	GetHealth() error
+	GetHealth() (*bus.ExplorerHealth, error)
+	GetDeepHealth() *bus.DeepHealth
+	GetReadiness() *bus.Readiness
 	GetStatus() *bus.ExplorerStatus
+	GetValidationState() (*bus.ValidationState, error)
+	GetRelayPolicy() (*bus.RelayPolicy, error)
+	GetIBDEstimate() (*bus.IBDEstimate, error)
 	GetFees(targets []int64, mode string) map[string]interface{}
+	GetFeeRates(targets []int64, mode string) map[string]interface{}
+	GetEvictionFee() (types.FeeRate, bool, error)
 }
 
 type ControlService interface {
 	ImportAccounts(accounts []config.Account)
 	HasDescriptor(descriptor string) (bool, error)
+	ListDescriptors(private bool) ([]bus.WalletDescriptor, error)
+}
+
+type WalletService interface {
+	GetBalances() (*types.Balances, error)
+	ListUTXOs(maxCount int, scriptType string) (*types.UTXOList, error)
+	GetUTXOsByScriptType() (map[string]types.ScriptTypeSummary, error)
+	GetTransactionWalletOutputs(hash string) ([]types.WalletOutput, error)
+	GetBlockWalletOutputs(ref string) ([]types.WalletOutput, error)
+	SyncAccount(descriptors []string, sinceBlock *string) (*types.AccountSync, error)
+	StreamAccountSync(descriptors []string, sinceBlock *string, done <-chan struct{}) (<-chan types.SyncEvent, error)
 }
 
 type ServiceInterface interface {
@@ -37,4 +75,5 @@ type ServiceInterface interface {
 	AddressesService
 	ExplorerService
 	ControlService
+	WalletService
 }
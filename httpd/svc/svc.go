@@ -1,7 +1,56 @@
 package svc
 
-import "github.com/ledgerhq/satstack/bus"
+import (
+	"time"
+
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/config"
+)
 
 type Service struct {
 	Bus *bus.Bus
+
+	// Denylist of addresses to flag in transaction inputs and outputs.
+	// Optional; can be nil.
+	Denylist config.Denylist
+
+	// Location to format the confirmation time of transactions and blocks
+	// in, in addition to UTC. Optional; can be nil.
+	Location *time.Location
+
+	// FinalityDepth is the number of confirmations after which transactions
+	// are labelled as finalized. Optional; defaultFinalityDepth if zero.
+	FinalityDepth uint64
+
+	// MinConf is the number of confirmations after which UTXOs are deemed
+	// safe to spend. Optional; defaultMinConf if zero.
+	MinConf int64
+}
+
+// defaultFinalityDepth is the finality depth used if none is configured. It
+// matches the coinbase maturity, after which a reorg is deemed impossible in
+// practice.
+const defaultFinalityDepth = 100
+
+// finalityDepth returns the configured finality depth, or the default.
+func (s *Service) finalityDepth() uint64 {
+	if s.FinalityDepth == 0 {
+		return defaultFinalityDepth
+	}
+
+	return s.FinalityDepth
+}
+
+// defaultMinConf is the minimum number of confirmations used if none is
+// configured, i.e., unconfirmed outputs are not safe to spend.
+const defaultMinConf = 1
+
+// minConf returns the configured minimum number of confirmations, or the
+// default.
+func (s *Service) minConf() int64 {
+	if s.MinConf == 0 {
+		return defaultMinConf
+	}
+
+	return s.MinConf
 }
@@ -0,0 +1,106 @@
+package svc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ledgerhq/satstack/bus"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// fakeNodeTip is the chain tip reported by the fake node.
+const fakeNodeTip = 700000
+
+// rpcResponder answers a JSON-RPC request of the fake node. It returns false
+// if the method is not handled, in which case the default startup answers
+// are used.
+type rpcResponder func(method string, params []json.RawMessage) (interface{}, *btcjson.RPCError, bool)
+
+// newFakeNodeService returns a Service whose Bus is connected to a fake
+// mainnet node at height fakeNodeTip, without transaction index. The
+// requests received by the node are tracked by method in calls.
+func newFakeNodeService(t *testing.T, respond rpcResponder) (*Service, map[string]int) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+			ID     json.RawMessage   `json:"id"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("invalid JSON-RPC request: %v", err)
+			return
+		}
+
+		mu.Lock()
+		calls[request.Method]++
+		mu.Unlock()
+
+		result, rpcErr, ok := respond(request.Method, request.Params)
+		if !ok {
+			result, rpcErr = startupResponse(t, request.Method)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": result,
+			"error":  rpcErr,
+			"id":     request.ID,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	b, err := bus.New(strings.TrimPrefix(server.URL, "http://"), "user", "pass", "", true)
+	if err != nil {
+		t.Fatalf("bus.New() error = %v", err)
+	}
+
+	// Only count the requests made by the test itself.
+	mu.Lock()
+	for method := range calls {
+		delete(calls, method)
+	}
+	mu.Unlock()
+
+	return &Service{Bus: b}, calls
+}
+
+// startupResponse answers the requests sent by bus.New and the backend
+// version detection of rpcclient, as well as the chain info.
+func startupResponse(t *testing.T, method string) (interface{}, *btcjson.RPCError) {
+	genesis := chaincfg.MainNetParams.GenesisHash.String()
+
+	switch method {
+	case "getblockchaininfo":
+		return map[string]interface{}{
+			"chain":         "main",
+			"blocks":        fakeNodeTip,
+			"headers":       fakeNodeTip,
+			"bestblockhash": genesis,
+		}, nil
+	case "getnetworkinfo":
+		return map[string]interface{}{"version": 220000}, nil
+	case "getblockhash":
+		return genesis, nil
+	case "getblock":
+		return map[string]interface{}{"hash": genesis, "tx": []string{genesis}}, nil
+	case "getblockfilter", "getrawtransaction":
+		return nil, &btcjson.RPCError{Code: btcjson.ErrRPCInvalidAddressOrKey, Message: "not found"}
+	case "loadwallet":
+		return map[string]interface{}{"name": "satstack"}, nil
+	case "getinfo":
+		// Identifies the node as bitcoind, rather than btcd.
+		return nil, btcjson.ErrRPCMethodNotFound
+	default:
+		t.Errorf("unexpected RPC method %s", method)
+		return nil, &btcjson.RPCError{Code: btcjson.ErrRPCMethodNotFound.Code, Message: "Method not found"}
+	}
+}
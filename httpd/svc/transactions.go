@@ -1,34 +1,307 @@
 package svc
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/config"
+	"github.com/ledgerhq/satstack/protocol"
 	"github.com/ledgerhq/satstack/types"
 	"github.com/ledgerhq/satstack/utils"
 
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcutil"
 	log "github.com/sirupsen/logrus"
 )
 
 // GetTransaction is a service function to query transaction details
 // by transaction hash.
+//
+// If block is nil, the block resolved by the Bus (if any) is retained.
 func (s *Service) GetTransaction(hash string, block *types.Block, bestBlockHeight int32) (*types.Transaction, error) {
+	return s.getTransaction(s.Bus.NewTxCache(), hash, block, bestBlockHeight)
+}
+
+// getTransaction is GetTransaction, with the transaction and its previous
+// outputs fetched through the given cache.
+func (s *Service) getTransaction(
+	txCache *bus.TxCache, hash string, block *types.Block, bestBlockHeight int32,
+) (*types.Transaction, error) {
+	getTransaction := txCache.GetTransaction
+	if block != nil {
+		getTransaction = txCache.GetTransactionWithoutBlock
+	}
+
+	tx, err := getTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := s.buildUTXOs(txCache, tx.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if block != nil {
+		tx.Block = block
+	}
+
+	buildTx(tx, utxos, bestBlockHeight, s.Bus.Params)
+	flagDenied(tx, s.Denylist)
+	localizeBlockTime(tx.Block, s.Location)
+	tx.StatusLabel = statusLabel(tx.Confirmations, s.finalityDepth())
+
+	return tx, nil
+}
+
+// GetTransactionAtTip is a service function to query transaction details by
+// transaction hash, with confirmations computed relative to the given tip
+// instead of the current one. If pinnedTip is nil, the current tip is used.
+//
+// Clients taking a snapshot over several calls can pin the same tip in each
+// of them, to get consistent data. The transaction is reported unconfirmed
+// if it was mined after the pinned tip, and bus.ErrPinnedTipReorged is
+// returned if the pinned tip is no longer on the main chain.
+//
+// In verbose mode, the disassembled signature script of the inputs is
+// included.
+func (s *Service) GetTransactionAtTip(hash string, pinnedTip *chainhash.Hash, verbose bool) (*types.Transaction, error) {
+	var err error
+	if pinnedTip == nil {
+		pinnedTip, err = s.Bus.GetBestBlockHash()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tip, err := s.pinnedTipHeader(pinnedTip)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := s.Bus.GetTransaction(hash)
 	if err != nil {
 		return nil, err
 	}
 
-	utxos, err := s.buildUTXOs(tx.Inputs)
+	// The block of the transaction is on the main chain, like the pinned
+	// tip, so it is an ancestor of the pinned tip if it is not higher.
+	block := tx.Block
+	if block != nil && block.Height > int64(tip.Height) {
+		block = nil
+	}
+
+	utxos, err := s.buildUTXOs(s.Bus.NewTxCache(), tx.Inputs)
 	if err != nil {
 		return nil, err
 	}
 
+	// Check the pinned tip again, in case of a reorg while querying the
+	// transaction.
+	if _, err := s.pinnedTipHeader(pinnedTip); err != nil {
+		return nil, err
+	}
+
 	tx.Block = block
-	buildTx(tx, utxos, bestBlockHeight)
+	buildTx(tx, utxos, tip.Height, s.Bus.Params)
+	flagDenied(tx, s.Denylist)
+	localizeBlockTime(tx.Block, s.Location)
+	tx.StatusLabel = statusLabel(tx.Confirmations, s.finalityDepth())
+
+	if verbose {
+		protocol.AnnotateScriptSigAsm(tx)
+	}
 
 	return tx, nil
 }
 
+// pinnedTipHeader returns the header of the pinned tip, if it is still on
+// the main chain.
+func (s *Service) pinnedTipHeader(pinnedTip *chainhash.Hash) (*btcjson.GetBlockHeaderVerboseResult, error) {
+	header, err := s.Bus.GetBlockHeader(pinnedTip)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bus.InMainChain(header) {
+		return nil, fmt.Errorf("%w: %s", bus.ErrPinnedTipReorged, pinnedTip)
+	}
+
+	return header, nil
+}
+
+// GetCanonicalTransaction is a service function to get the canonical
+// representation of a transaction by hash, for display on a hardware wallet.
+//
+// All the previous outputs must be resolved, otherwise ErrUnresolvedPrevout
+// is returned. Coinbase inputs are omitted, and the fee of a coinbase
+// transaction is the total fees of its block.
+func (s *Service) GetCanonicalTransaction(hash string) (*types.CanonicalTransaction, error) {
+	tx, err := s.Bus.GetTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := s.buildUTXOs(s.Bus.NewTxCache(), tx.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	canonical := types.CanonicalTransaction{
+		Hash:    tx.Hash,
+		Inputs:  []types.CanonicalInput{},
+		Outputs: []types.CanonicalOutput{},
+	}
+
+	for _, input := range tx.Inputs {
+		if len(input.Coinbase) > 0 {
+			continue
+		}
+
+		utxo, err := resolvePrevout(input, utxos)
+		if err != nil {
+			return nil, err
+		}
+
+		canonical.Inputs = append(canonical.Inputs, types.CanonicalInput{
+			OutputHash:  input.OutputHash,
+			OutputIndex: *input.OutputIndex,
+			Address:     utxo.Address,
+			Value:       utxo.Value,
+		})
+		canonical.Fee += utxo.Value
+	}
+
+	for _, output := range tx.Outputs {
+		canonical.Outputs = append(canonical.Outputs, types.CanonicalOutput{
+			Address:   output.Address,
+			ScriptHex: output.ScriptHex,
+			Value:     *output.Value,
+		})
+	}
+
+	var sumOutputs btcutil.Amount
+	for _, output := range canonical.Outputs {
+		sumOutputs += output.Value
+	}
+
+	if tx.IsCoinbase() {
+		canonical.Fee = coinbaseFees(sumOutputs, tx.Block, s.Bus.Params)
+	} else {
+		canonical.Fee -= sumOutputs
+	}
+
+	sortCanonical(&canonical)
+
+	return &canonical, nil
+}
+
+// GetTransactionFlow is a service function to get the value flow of a
+// transaction, from the previous outputs spent by its inputs to its outputs,
+// for visualization as a Sankey diagram. The fee is the difference.
+//
+// The previous outputs must be resolved, so that the flows balance. For a
+// coinbase transaction, the subsidy and the fees collected from the block
+// are modelled as a single input flow, and the fee is the total fees of the
+// block. It is part of the input flow, and not deducted from it.
+func (s *Service) GetTransactionFlow(hash string) (*types.TransactionFlow, error) {
+	tx, err := s.Bus.GetTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := s.buildUTXOs(s.Bus.NewTxCache(), tx.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	flow := types.TransactionFlow{
+		Hash:    tx.Hash,
+		Inputs:  make([]types.Flow, 0, len(tx.Inputs)),
+		Outputs: make([]types.Flow, 0, len(tx.Outputs)),
+	}
+
+	var sumOutputs btcutil.Amount
+	for idx, output := range tx.Outputs {
+		flow.Outputs = append(flow.Outputs, types.Flow{
+			Index:   idx,
+			Address: output.Address,
+			Value:   *output.Value,
+		})
+		sumOutputs += *output.Value
+	}
+
+	if tx.IsCoinbase() {
+		flow.Inputs = append(flow.Inputs, types.Flow{
+			Coinbase: true,
+			Value:    sumOutputs,
+		})
+		flow.Fee = coinbaseFees(sumOutputs, tx.Block, s.Bus.Params)
+
+		return &flow, nil
+	}
+
+	var sumInputs btcutil.Amount
+	for idx, input := range tx.Inputs {
+		utxo, err := resolvePrevout(input, utxos)
+		if err != nil {
+			return nil, err
+		}
+
+		flow.Inputs = append(flow.Inputs, types.Flow{
+			Index:   idx,
+			Address: utxo.Address,
+			Value:   utxo.Value,
+		})
+		sumInputs += utxo.Value
+	}
+
+	flow.Fee = sumInputs - sumOutputs
+
+	return &flow, nil
+}
+
+// resolvePrevout returns the previous output spent by a non-coinbase input,
+// or ErrUnresolvedPrevout if it is missing from utxos.
+func resolvePrevout(input types.Input, utxos types.UTXOs) (types.UTXOData, error) {
+	outpoint := types.OutputIdentifier{
+		Hash:  input.OutputHash,
+		Index: *input.OutputIndex,
+	}
+
+	utxo, ok := utxos[outpoint]
+	if !ok {
+		return types.UTXOData{}, fmt.Errorf("%w: %s:%d", ErrUnresolvedPrevout, outpoint.Hash, outpoint.Index)
+	}
+
+	return utxo, nil
+}
+
+// sortCanonical sorts the inputs and outputs of a canonical transaction,
+// according to BIP-0069.
+func sortCanonical(tx *types.CanonicalTransaction) {
+	sort.Slice(tx.Inputs, func(i, j int) bool {
+		if tx.Inputs[i].OutputHash != tx.Inputs[j].OutputHash {
+			return tx.Inputs[i].OutputHash < tx.Inputs[j].OutputHash
+		}
+
+		return tx.Inputs[i].OutputIndex < tx.Inputs[j].OutputIndex
+	})
+
+	sort.Slice(tx.Outputs, func(i, j int) bool {
+		if tx.Outputs[i].Value != tx.Outputs[j].Value {
+			return tx.Outputs[i].Value < tx.Outputs[j].Value
+		}
+
+		return tx.Outputs[i].ScriptHex < tx.Outputs[j].ScriptHex
+	})
+}
+
 // GetTransactionHex is a service function to get hex encoded raw
 // transaction by hash.
 func (s *Service) GetTransactionHex(hash string) (string, error) {
@@ -40,15 +313,210 @@ func (s *Service) GetTransactionHex(hash string) (string, error) {
 	return s.Bus.GetTransactionHex(chainHash)
 }
 
-func (s *Service) SendTransaction(tx string) (string, error) {
-	hash, err := s.Bus.SendTransaction(tx)
+// GetConfirmationDelay is a service function to get the number of blocks it
+// took for a transaction to be confirmed, since it was first seen in the
+// mempool.
+func (s *Service) GetConfirmationDelay(hash string) (int64, error) {
+	chainHash, err := utils.ParseChainHash(hash)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
-	return hash.String(), nil
+
+	return s.Bus.ConfirmationDelay(chainHash)
 }
 
-func (s *Service) buildUTXOs(vin []types.Input) (types.UTXOs, error) {
+// GetBlockAtDepth is a service function to get the block that is depth
+// blocks above the block of a confirmed transaction, i.e., at height
+// txBlockHeight + depth. A depth of 0 returns the block of the transaction.
+//
+// It returns a *bus.ErrBlockHeightOutOfRange if the block was not mined yet.
+func (s *Service) GetBlockAtDepth(hash string, depth int64) (*types.Block, error) {
+	if depth < 0 {
+		return nil, fmt.Errorf("invalid depth '%d'", depth)
+	}
+
+	chainHash, err := utils.ParseChainHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	txBlock, err := s.Bus.GetTransactionBlock(chainHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if txBlock == nil {
+		return nil, bus.ErrTransactionUnconfirmed
+	}
+
+	blockHash, err := s.getBlockHashByHeight(txBlock.Height + depth)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Bus.GetBlock(blockHash)
+}
+
+// GetReplaceability is a service function to get the BIP-0125
+// replaceability of an unconfirmed transaction, including replaceability
+// inherited from its unconfirmed ancestors.
+func (s *Service) GetReplaceability(hash string) (*types.Replaceability, error) {
+	chainHash, err := utils.ParseChainHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Bus.GetReplaceability(chainHash)
+}
+
+// GetMempoolRank is a service function to get the position of an
+// unconfirmed transaction in the mempool, sorted by fee rate.
+func (s *Service) GetMempoolRank(hash string) (*types.MempoolRank, error) {
+	chainHash, err := utils.ParseChainHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Bus.GetMempoolRank(chainHash)
+}
+
+// GetMempoolDescendants is a service function to get the descendants of an
+// unconfirmed transaction in the mempool, which would be evicted if it were
+// replaced.
+func (s *Service) GetMempoolDescendants(hash string) (*types.MempoolDescendants, error) {
+	chainHash, err := utils.ParseChainHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Bus.GetMempoolDescendants(chainHash)
+}
+
+// GetTransactionFinality is a service function to check whether a
+// transaction can be included in the next block, given its absolute and
+// relative lock times, and the current tip.
+//
+// Resolving the block confirming the outputs spent with a relative lock time
+// requires a transaction index, unless they belong to the wallet.
+func (s *Service) GetTransactionFinality(hash string) (*types.Finality, error) {
+	chainHash, err := utils.ParseChainHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	msgTx, err := s.Bus.GetMsgTx(chainHash)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.Bus.GetBlockChainInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	prevouts := make([]*protocol.PrevoutConfirmation, len(msgTx.TxIn))
+	for i, txIn := range msgTx.TxIn {
+		if !protocol.HasRelativeLock(msgTx, txIn) {
+			continue
+		}
+
+		block, err := s.Bus.GetTransactionBlock(&txIn.PreviousOutPoint.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if block == nil {
+			// Unconfirmed output
+			continue
+		}
+
+		medianTime, err := s.Bus.GetParentMedianTime(block.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		prevouts[i] = &protocol.PrevoutConfirmation{
+			Height:         block.Height,
+			MedianTimePast: medianTime,
+		}
+	}
+
+	return protocol.TxFinality(msgTx, int64(info.Blocks), info.MedianTime, prevouts), nil
+}
+
+// GetInputWeights is a service function to get the weight contribution and
+// spend type of each input of a transaction.
+func (s *Service) GetInputWeights(hash string) ([]types.InputWeight, error) {
+	chainHash, err := utils.ParseChainHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	msgTx, err := s.Bus.GetMsgTx(chainHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return protocol.InputWeights(msgTx), nil
+}
+
+// GetSigHashTypes is a service function to get the sighash types of the
+// signatures of each input of a transaction by hash.
+func (s *Service) GetSigHashTypes(hash string) ([]types.InputSigHashes, error) {
+	chainHash, err := utils.ParseChainHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	msgTx, err := s.Bus.GetMsgTx(chainHash)
+	if err != nil {
+		return nil, err
+	}
+
+	sigHashTypes := protocol.SigHashTypes(msgTx)
+
+	result := make([]types.InputSigHashes, len(sigHashTypes))
+	for i, hashTypes := range sigHashTypes {
+		names := make([]string, len(hashTypes))
+		for j, hashType := range hashTypes {
+			names[j] = protocol.SigHashName(hashType)
+		}
+
+		result[i] = types.InputSigHashes{InputIndex: i, SigHashTypes: names}
+	}
+
+	return result, nil
+}
+
+// GetTransactionSummary is a service function to get the input and output
+// counts, and total output value of a transaction by hash.
+func (s *Service) GetTransactionSummary(hash string) (*types.TransactionSummary, error) {
+	chainHash, err := utils.ParseChainHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	msgTx, err := s.Bus.GetMsgTx(chainHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return protocol.SummarizeMsgTx(msgTx), nil
+}
+
+func (s *Service) SendTransaction(tx string) (*bus.BroadcastResult, error) {
+	return s.Bus.SendTransaction(tx)
+}
+
+// TestMempoolAccept is a service function to check whether a package of raw
+// transactions would be accepted in the mempool, without broadcasting it.
+func (s *Service) TestMempoolAccept(txs []string) ([]bus.MempoolAcceptResult, error) {
+	return s.Bus.TestMempoolAccept(txs)
+}
+
+// buildUTXOs resolves the outputs spent by the given inputs, through the
+// given cache. Outputs that cannot be resolved are omitted.
+func (s *Service) buildUTXOs(txCache *bus.TxCache, vin []types.Input) (types.UTXOs, error) {
 	utxoMap := make(types.UTXOs)
 
 	for _, inputRaw := range vin {
@@ -61,7 +529,7 @@ func (s *Service) buildUTXOs(vin []types.Input) (types.UTXOs, error) {
 			Index: *inputRaw.OutputIndex, // FIXME: can panic
 		}
 
-		utxo, err := s.Bus.GetTransaction(utxoID.Hash)
+		utxo, err := txCache.GetTransactionWithoutBlock(utxoID.Hash)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"error": err,
@@ -72,21 +540,100 @@ func (s *Service) buildUTXOs(vin []types.Input) (types.UTXOs, error) {
 		}
 
 		utxoMap[utxoID] = types.UTXOData{
-			Value:   *utxo.Outputs[utxoID.Index].Value, // FIXME: can panic
-			Address: utxo.Outputs[utxoID.Index].Address,
+			Value:    *utxo.Outputs[utxoID.Index].Value, // FIXME: can panic
+			Address:  utxo.Outputs[utxoID.Index].Address,
+			IsSegwit: protocol.IsWitnessScript(utxo.Outputs[utxoID.Index].ScriptHex),
 		}
 	}
 
 	return utxoMap, nil
 }
 
-func buildTx(tx *types.Transaction, utxoMap types.UTXOs, bestBlockHeight int32) {
+// safeConfirmations is the number of confirmations from which a transaction
+// is conventionally considered safe, if not finalized yet.
+const safeConfirmations = 6
+
+// statusLabel returns a human-readable label of the confirmation depth of a
+// transaction, given the number of confirmations after which it is final.
+func statusLabel(confirmations uint64, finalityDepth uint64) string {
+	switch {
+	case confirmations == 0:
+		return "Unconfirmed"
+	case confirmations >= finalityDepth:
+		return "Finalized"
+	case confirmations == 1:
+		return "1 confirmation"
+	case confirmations < safeConfirmations:
+		return fmt.Sprintf("%d confirmations", confirmations)
+	default:
+		return fmt.Sprintf("%d+ confirmations", safeConfirmations)
+	}
+}
+
+// localizeBlockTime populates the time of the block in the given location,
+// if any.
+func localizeBlockTime(block *types.Block, location *time.Location) {
+	if block == nil || location == nil {
+		return
+	}
+
+	block.LocalTime = utils.FormatTimestampIn(block.Time, location)
+}
+
+// flagDenied marks the inputs and outputs of the transaction whose address is
+// on the denylist.
+//
+// Input addresses are only known after resolution of the UTXOs, so it must be
+// called after buildTx.
+func flagDenied(tx *types.Transaction, denylist config.Denylist) {
+	for idx := range tx.Inputs {
+		tx.Inputs[idx].Denied = denylist.Contains(tx.Inputs[idx].Address)
+	}
+
+	for idx := range tx.Outputs {
+		tx.Outputs[idx].Denied = denylist.Contains(tx.Outputs[idx].Address)
+	}
+}
+
+// coinbaseFees returns the total fees of the block of a coinbase transaction,
+// i.e., the value of the coinbase outputs above the block subsidy. This is
+// the fee reported for coinbase transactions by the transaction endpoints.
+// The /blocks/:block/fees endpoint reports a fee of 0 for the coinbase
+// instead, since it lists the fees paid by each transaction.
+//
+// Miners may claim less than the subsidy plus fees, in which case the excess
+// is lost. If they claim less than the subsidy alone, no fees were collected
+// and 0 is returned. The block is required to know the subsidy, so 0 is
+// returned if it is unknown.
+func coinbaseFees(sumVoutValues btcutil.Amount, block *types.Block, params *chaincfg.Params) btcutil.Amount {
+	if block == nil || block.Height < 0 {
+		return 0
+	}
+
+	subsidy := btcutil.Amount(blockchain.CalcBlockSubsidy(int32(block.Height), params))
+	if sumVoutValues < subsidy {
+		return 0
+	}
+
+	return sumVoutValues - subsidy
+}
+
+// feePercent returns the fee as a percentage of the total output value, or
+// nil if either of them is zero.
+func feePercent(fees btcutil.Amount, sumVoutValues btcutil.Amount) *float64 {
+	if fees <= 0 || sumVoutValues <= 0 {
+		return nil
+	}
+
+	percent := float64(fees) / float64(sumVoutValues) * 100
+	return &percent
+}
+
+func buildTx(tx *types.Transaction, utxoMap types.UTXOs, bestBlockHeight int32, params *chaincfg.Params) {
 	sumVinValues := btcutil.Amount(0)
-	vinHasCoinbase := false
 
 	for idx, vin := range tx.Inputs {
 		if len(vin.Coinbase) > 0 {
-			vinHasCoinbase = true
 			continue
 		}
 
@@ -100,6 +647,11 @@ func buildTx(tx *types.Transaction, utxoMap types.UTXOs, bestBlockHeight int32)
 		tx.Inputs[idx].Address = utxo.Address // mutate the vins in tx
 		tx.Inputs[idx].Value = &utxo.Value
 
+		// Spending a segwit output requires witness data, which is the
+		// only way to detect nested segwit outputs. Native segwit outputs
+		// can also be detected from the script of the resolved UTXO.
+		tx.Inputs[idx].PrevoutIsSegwit = utxo.IsSegwit || len(vin.Witness) > 0
+
 		sumVinValues += utxo.Value
 	}
 
@@ -120,9 +672,10 @@ func buildTx(tx *types.Transaction, utxoMap types.UTXOs, bestBlockHeight int32)
 
 	var fees btcutil.Amount
 
-	if vinHasCoinbase {
-		// Coinbase transactions have no fees
-		fees = btcutil.Amount(0)
+	if tx.IsCoinbase() {
+		// Coinbase transactions have no inputs to resolve. Report the
+		// fees collected from the transactions of the block instead.
+		fees = coinbaseFees(sumVoutValues, tx.Block, params)
 	} else {
 		fees = sumVinValues - sumVoutValues
 	}
@@ -135,6 +688,13 @@ func buildTx(tx *types.Transaction, utxoMap types.UTXOs, bestBlockHeight int32)
 
 	tx.Fees = &fees
 
+	if !tx.IsCoinbase() && fees > 0 {
+		tx.FeePercentOfOutputs = feePercent(fees, sumVoutValues)
+		tx.FeeRate = protocol.FeeRateForVSize(fees, tx.VSize)
+	}
+
+	tx.DistinctInputAddresses, tx.DistinctOutputAddresses = tx.DistinctAddressCounts()
+
 	// In Ledger Blockchain Explorer v2, the Amount field is the sum of all
 	// Vout values.
 	tx.Amount = &sumVoutValues
@@ -0,0 +1,351 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ledgerhq/satstack/config"
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// subsidyAt680000 is the block subsidy on mainnet after the third halving.
+const subsidyAt680000 = btcutil.Amount(625000000)
+
+func TestCoinbaseFees(t *testing.T) {
+	block := &types.Block{Height: 680000}
+
+	tests := []struct {
+		name          string
+		sumVoutValues btcutil.Amount
+		block         *types.Block
+		want          btcutil.Amount
+	}{
+		{"outputs above the subsidy", subsidyAt680000 + 15000000, block, 15000000},
+		{"outputs equal to the subsidy", subsidyAt680000, block, 0},
+		{"outputs below the subsidy", subsidyAt680000 - 1, block, 0},
+		{"genesis subsidy", 5000000000, &types.Block{Height: 0}, 0},
+		{"unconfirmed", subsidyAt680000 + 15000000, &types.Block{Height: -1}, 0},
+		{"unknown block", subsidyAt680000 + 15000000, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := coinbaseFees(tt.sumVoutValues, tt.block, &chaincfg.MainNetParams)
+			if got != tt.want {
+				t.Errorf("coinbaseFees() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTxCoinbaseFees(t *testing.T) {
+	blockFees := btcutil.Amount(15000000)
+	minerReward := subsidyAt680000 + blockFees - 1000
+	witnessCommitment := btcutil.Amount(0)
+	outputIndex := uint32(0)
+
+	tx := types.Transaction{
+		Inputs: []types.Input{
+			{Coinbase: "03405f0a"},
+		},
+		Outputs: []types.Output{
+			{OutputIndex: &outputIndex, Value: &minerReward},
+			{Value: &witnessCommitment},
+		},
+		Block: &types.Block{Height: 680000},
+		VSize: 150,
+	}
+
+	// The claimed reward is 1000 satoshis below the subsidy plus fees, which
+	// are lost and not reported.
+	buildTx(&tx, types.UTXOs{}, 680005, &chaincfg.MainNetParams)
+
+	if tx.Fees == nil || *tx.Fees != blockFees-1000 {
+		t.Fatalf("buildTx() fees = %v, want %d", tx.Fees, blockFees-1000)
+	}
+
+	if tx.FeeRate != nil {
+		t.Errorf("buildTx() fee rate = %v, want nil for a coinbase transaction", tx.FeeRate)
+	}
+
+	if tx.Confirmations != 6 {
+		t.Errorf("buildTx() confirmations = %d, want 6", tx.Confirmations)
+	}
+}
+
+func TestStatusLabel(t *testing.T) {
+	tests := []struct {
+		confirmations uint64
+		finalityDepth uint64
+		want          string
+	}{
+		{0, defaultFinalityDepth, "Unconfirmed"},
+		{1, defaultFinalityDepth, "1 confirmation"},
+		{2, defaultFinalityDepth, "2 confirmations"},
+		{5, defaultFinalityDepth, "5 confirmations"},
+		{6, defaultFinalityDepth, "6+ confirmations"},
+		{99, defaultFinalityDepth, "6+ confirmations"},
+		{100, defaultFinalityDepth, "Finalized"},
+		{150, defaultFinalityDepth, "Finalized"},
+		{3, 3, "Finalized"},
+		{1, 1, "Finalized"},
+		{0, 1, "Unconfirmed"},
+	}
+
+	for _, tt := range tests {
+		if got := statusLabel(tt.confirmations, tt.finalityDepth); got != tt.want {
+			t.Errorf("statusLabel(%d, %d) = %q, want %q", tt.confirmations, tt.finalityDepth, got, tt.want)
+		}
+	}
+}
+
+func TestFinalityDepth(t *testing.T) {
+	if got := (&Service{}).finalityDepth(); got != defaultFinalityDepth {
+		t.Errorf("finalityDepth() = %d, want the default %d", got, defaultFinalityDepth)
+	}
+
+	if got := (&Service{FinalityDepth: 6}).finalityDepth(); got != 6 {
+		t.Errorf("finalityDepth() = %d, want the configured 6", got)
+	}
+}
+
+func TestSortCanonical(t *testing.T) {
+	tx := types.CanonicalTransaction{
+		Inputs: []types.CanonicalInput{
+			{OutputHash: "e2f6c3ea2b0e1d6ff7c2e1a9a0f9b7e0d1c2b3a4f5e6d7c8b9a0f1e2d3c4b5a6", OutputIndex: 0},
+			{OutputHash: "0e53ec5dfb2cb8a71fec32dc9a634a35b7e24799295ddd5278217822e0b31f57", OutputIndex: 1},
+			{OutputHash: "0e53ec5dfb2cb8a71fec32dc9a634a35b7e24799295ddd5278217822e0b31f57", OutputIndex: 0},
+			{OutputHash: "26aa6e6d8b9e49bb0630aac301db6757c02e3619feb4ee0eea81eb1672947024", OutputIndex: 1},
+		},
+		Outputs: []types.CanonicalOutput{
+			{ScriptHex: "76a9144a5fba237213a062f6f57978f796390bdcf8d01588ac", Value: 400057456},
+			{ScriptHex: "0014751e76e8199196d454941c45d1b3a323f1433bd6", Value: 100000000},
+			{ScriptHex: "00145d6f02f47dc6c57093df246e3742cfe1e22ab410", Value: 100000000},
+		},
+	}
+
+	sortCanonical(&tx)
+
+	wantInputs := []types.CanonicalInput{
+		{OutputHash: "0e53ec5dfb2cb8a71fec32dc9a634a35b7e24799295ddd5278217822e0b31f57", OutputIndex: 0},
+		{OutputHash: "0e53ec5dfb2cb8a71fec32dc9a634a35b7e24799295ddd5278217822e0b31f57", OutputIndex: 1},
+		{OutputHash: "26aa6e6d8b9e49bb0630aac301db6757c02e3619feb4ee0eea81eb1672947024", OutputIndex: 1},
+		{OutputHash: "e2f6c3ea2b0e1d6ff7c2e1a9a0f9b7e0d1c2b3a4f5e6d7c8b9a0f1e2d3c4b5a6", OutputIndex: 0},
+	}
+
+	// Outputs of equal value are ordered by their script.
+	wantOutputs := []types.CanonicalOutput{
+		{ScriptHex: "00145d6f02f47dc6c57093df246e3742cfe1e22ab410", Value: 100000000},
+		{ScriptHex: "0014751e76e8199196d454941c45d1b3a323f1433bd6", Value: 100000000},
+		{ScriptHex: "76a9144a5fba237213a062f6f57978f796390bdcf8d01588ac", Value: 400057456},
+	}
+
+	if !reflect.DeepEqual(tx.Inputs, wantInputs) {
+		t.Errorf("sortCanonical() inputs = %+v, want %+v", tx.Inputs, wantInputs)
+	}
+
+	if !reflect.DeepEqual(tx.Outputs, wantOutputs) {
+		t.Errorf("sortCanonical() outputs = %+v, want %+v", tx.Outputs, wantOutputs)
+	}
+}
+
+func TestLocalizeBlockTime(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		name     string
+		block    *types.Block
+		location *time.Location
+		want     string
+	}{
+		{"localized", &types.Block{Time: "2021-03-14T15:09:26Z"}, tokyo, "2021-03-15T00:09:26+09:00"},
+		{"no timezone", &types.Block{Time: "2021-03-14T15:09:26Z"}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			localizeBlockTime(tt.block, tt.location)
+			if tt.block.LocalTime != tt.want {
+				t.Errorf("LocalTime = %q, want %q", tt.block.LocalTime, tt.want)
+			}
+		})
+	}
+
+	// Unconfirmed transactions have no block.
+	localizeBlockTime(nil, tokyo)
+}
+
+func TestFeePercent(t *testing.T) {
+	percent := func(value float64) *float64 {
+		return &value
+	}
+
+	tests := []struct {
+		name          string
+		fees          btcutil.Amount
+		sumVoutValues btcutil.Amount
+		want          *float64
+	}{
+		{"one percent", 1000, 100000, percent(1)},
+		{"fees above outputs", 300000, 100000, percent(300)},
+		{"no fees", 0, 100000, nil},
+		{"no outputs", 1000, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := feePercent(tt.fees, tt.sumVoutValues)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("feePercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// serializeMsgTx returns the hex-encoded serialization of a transaction.
+func serializeMsgTx(t *testing.T, msgTx *wire.MsgTx) string {
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func TestGetTransactionFlow(t *testing.T) {
+	const (
+		addrA = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+		addrB = "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"
+		addrC = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	)
+
+	pkScript := func(address string) []byte {
+		addr, err := btcutil.DecodeAddress(address, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("DecodeAddress() error = %v", err)
+		}
+
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("PayToAddrScript() error = %v", err)
+		}
+
+		return script
+	}
+
+	external, _ := chainhash.NewHashFromStr("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b")
+	parent := wire.NewMsgTx(wire.TxVersion)
+	parent.AddTxIn(wire.NewTxIn(wire.NewOutPoint(external, 0), nil, nil))
+	parent.AddTxOut(wire.NewTxOut(60000, pkScript(addrA)))
+	parent.AddTxOut(wire.NewTxOut(50000, pkScript(addrB)))
+
+	parentHash := parent.TxHash()
+	child := wire.NewMsgTx(wire.TxVersion)
+	child.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&parentHash, 0), nil, nil))
+	child.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&parentHash, 1), nil, nil))
+	child.AddTxOut(wire.NewTxOut(100000, pkScript(addrC)))
+
+	rawTxs := map[string]string{
+		parentHash.String():     serializeMsgTx(t, parent),
+		child.TxHash().String(): serializeMsgTx(t, child),
+	}
+
+	s, _ := newFakeNodeService(t, func(method string, params []json.RawMessage) (interface{}, *btcjson.RPCError, bool) {
+		if method != "gettransaction" {
+			return nil, nil, false
+		}
+
+		var hash string
+		if err := json.Unmarshal(params[0], &hash); err != nil {
+			t.Errorf("invalid gettransaction params: %v", err)
+		}
+
+		raw, ok := rawTxs[hash]
+		if !ok {
+			return nil, &btcjson.RPCError{Code: btcjson.ErrRPCInvalidAddressOrKey, Message: "not found"}, true
+		}
+
+		// Unconfirmed, so that no block header is queried.
+		return map[string]interface{}{"txid": hash, "hex": raw}, nil, true
+	})
+
+	flow, err := s.GetTransactionFlow(child.TxHash().String())
+	if err != nil {
+		t.Fatalf("GetTransactionFlow() error = %v", err)
+	}
+
+	wantInputs := []types.Flow{
+		{Index: 0, Address: addrA, Value: 60000},
+		{Index: 1, Address: addrB, Value: 50000},
+	}
+	if !reflect.DeepEqual(flow.Inputs, wantInputs) {
+		t.Errorf("GetTransactionFlow() inputs = %+v, want %+v", flow.Inputs, wantInputs)
+	}
+
+	var sumInputs, sumOutputs btcutil.Amount
+	for _, input := range flow.Inputs {
+		sumInputs += input.Value
+	}
+
+	for _, output := range flow.Outputs {
+		sumOutputs += output.Value
+	}
+
+	if flow.Fee != 10000 || sumInputs != sumOutputs+flow.Fee {
+		t.Errorf("GetTransactionFlow() inputs %d != outputs %d + fee %d", sumInputs, sumOutputs, flow.Fee)
+	}
+}
+
+func TestFlagDenied(t *testing.T) {
+	const (
+		denied  = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+		allowed = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+	)
+
+	// The denylist entry is uppercase, while the node reports lowercase
+	// bech32 addresses.
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	if err := ioutil.WriteFile(path, []byte(strings.ToUpper(denied)+"\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	denylist, err := config.LoadDenylist(path)
+	if err != nil {
+		t.Fatalf("LoadDenylist() error = %v", err)
+	}
+
+	tx := &types.Transaction{
+		Inputs:  []types.Input{{Address: denied}, {Address: allowed}},
+		Outputs: []types.Output{{Address: allowed}, {Address: denied}, {}},
+	}
+
+	flagDenied(tx, denylist)
+
+	wantInputs := []bool{true, false}
+	for i, input := range tx.Inputs {
+		if input.Denied != wantInputs[i] {
+			t.Errorf("input %d denied = %v, want %v", i, input.Denied, wantInputs[i])
+		}
+	}
+
+	wantOutputs := []bool{false, true, false}
+	for i, output := range tx.Outputs {
+		if output.Denied != wantOutputs[i] {
+			t.Errorf("output %d denied = %v, want %v", i, output.Denied, wantOutputs[i])
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package svc
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/types"
+	"github.com/ledgerhq/satstack/utils"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// GetBalances is a service method to get the balance of the wallet, split by
+// confirmation status.
+func (s *Service) GetBalances() (*types.Balances, error) {
+	return s.Bus.GetBalances()
+}
+
+// ListUTXOs is a service method to get the UTXOs of the wallet, sorted by
+// outpoint so that the result is deterministic.
+//
+// If scriptType is not empty, only the UTXOs of this script type are
+// returned, for ex: witness_v0_keyhash or witness_v1_taproot.
+//
+// At most maxCount UTXOs are returned, and the result is flagged as truncated
+// if the wallet has more. A maxCount of zero or less means no limit.
+//
+// Each returned UTXO is flagged as safe to spend or not, for coin selection.
+func (s *Service) ListUTXOs(maxCount int, scriptType string) (*types.UTXOList, error) {
+	utxos, err := s.Bus.ListUnspent()
+	if err != nil {
+		return nil, err
+	}
+
+	if scriptType != "" {
+		utxos = filterByScriptType(utxos, scriptType)
+	}
+
+	result := truncateUTXOs(utxos, maxCount)
+
+	statuses, err := s.Bus.GetOutputStatuses(result.UTXOs)
+	if err != nil {
+		return nil, err
+	}
+
+	maturity := int64(s.Bus.Params.CoinbaseMaturity)
+	for i := range result.UTXOs {
+		utxo := &result.UTXOs[i]
+
+		outpoint := types.OutputIdentifier{Hash: utxo.OutputHash, Index: utxo.OutputIndex}
+		safe := isSafeToSpend(*utxo, statuses[outpoint], s.minConf(), maturity)
+		utxo.SafeToSpend = &safe
+	}
+
+	return result, nil
+}
+
+// isSafeToSpend checks if a UTXO can be selected to fund a new transaction,
+// i.e., it has at least minConf confirmations, it is mature if created by a
+// coinbase transaction, it is not locked, and it is not already spent by an
+// unconfirmed transaction.
+//
+// A coinbase output can be spent in the next block once it has maturity
+// confirmations, which is also when the node accepts the spending
+// transaction in its mempool.
+func isSafeToSpend(utxo types.UTXO, status bus.OutputStatus, minConf int64, maturity int64) bool {
+	switch {
+	case utxo.Confirmations < minConf:
+		return false
+	case status.Coinbase && utxo.Confirmations < maturity:
+		return false
+	case status.Locked, status.SpentInMempool:
+		return false
+	default:
+		return true
+	}
+}
+
+// filterByScriptType returns the UTXOs of the given script type.
+func filterByScriptType(utxos []types.UTXO, scriptType string) []types.UTXO {
+	result := make([]types.UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if utxo.ScriptType == scriptType {
+			result = append(result, utxo)
+		}
+	}
+
+	return result
+}
+
+// truncateUTXOs sorts the UTXOs by outpoint, and keeps the first maxCount
+// of them.
+func truncateUTXOs(utxos []types.UTXO, maxCount int) *types.UTXOList {
+	sort.Slice(utxos, func(i, j int) bool {
+		if utxos[i].OutputHash != utxos[j].OutputHash {
+			return utxos[i].OutputHash < utxos[j].OutputHash
+		}
+
+		return utxos[i].OutputIndex < utxos[j].OutputIndex
+	})
+
+	result := types.UTXOList{
+		Total: len(utxos),
+		UTXOs: utxos,
+	}
+
+	if maxCount > 0 && len(utxos) > maxCount {
+		result.Truncated = true
+		result.UTXOs = utxos[:maxCount]
+	}
+
+	return &result
+}
+
+// GetUTXOsByScriptType is a service method to get the value and number of
+// UTXOs of the wallet, aggregated by script type.
+func (s *Service) GetUTXOsByScriptType() (map[string]types.ScriptTypeSummary, error) {
+	utxos, err := s.Bus.ListUnspent()
+	if err != nil {
+		return nil, err
+	}
+
+	return aggregateByScriptType(utxos), nil
+}
+
+// aggregateByScriptType sums the values of the UTXOs of each script type.
+func aggregateByScriptType(utxos []types.UTXO) map[string]types.ScriptTypeSummary {
+	result := make(map[string]types.ScriptTypeSummary)
+	for _, utxo := range utxos {
+		summary := result[utxo.ScriptType]
+		summary.Count++
+		summary.Value += utxo.Value
+		result[utxo.ScriptType] = summary
+	}
+
+	return result
+}
+
+// syncAccountDepth is the number of addresses derived from each descriptor,
+// to look up the UTXOs and transactions of an account. It matches the
+// default depth of imported accounts.
+const syncAccountDepth = 1000
+
+// syncAccountFeeTargets are the confirmation targets of the fee estimates
+// returned by SyncAccount. Same as the defaults of the fees endpoint.
+var syncAccountFeeTargets = []int64{2, 3, 6}
+
+// SyncAccount is a service method to get everything required to synchronize
+// an account described by its descriptors, since the given block.
+//
+// The addresses are derived locally from the descriptors, and the node is
+// queried with a single batch of RPC requests. Only the transactions need to
+// be resolved individually.
+func (s *Service) SyncAccount(descriptors []string, sinceBlock *string) (*types.AccountSync, error) {
+	addresses, err := s.deriveAccountAddresses(descriptors)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.Bus.GetAccountSnapshot(
+		addresses, sinceBlock, syncAccountFeeTargets, "CONSERVATIVE")
+	if err != nil {
+		return nil, err
+	}
+
+	txs := s.buildAddressTransactions(
+		s.Bus.NewTxCache(), addresses, snapshot.Transactions, snapshot.Info.Headers)
+
+	fees := make(map[string]btcutil.Amount, len(snapshot.Fees))
+	for target, fee := range snapshot.Fees {
+		fees[strconv.FormatInt(target, 10)] = fee
+	}
+
+	return &types.AccountSync{
+		BlockHeight:  int64(snapshot.Info.Blocks),
+		BlockHash:    snapshot.Info.BestBlockHash,
+		UTXOs:        snapshot.UTXOs,
+		Transactions: txs,
+		Fees:         fees,
+		LastBlock:    snapshot.LastBlock,
+	}, nil
+}
+
+// StreamAccountSync is a service method to stream the transactions of an
+// account described by its descriptors, confirmed since the given block (or
+// all, if nil).
+//
+// Transactions removed from the main chain by a reorg are emitted first,
+// followed by the newly confirmed ones. Each event is emitted as soon as the
+// transaction is resolved, without waiting for the rest of the history. The
+// final event carries the block to pass as sinceBlock in the next call. The
+// channel is closed after the final event, or as soon as done is closed.
+func (s *Service) StreamAccountSync(
+	descriptors []string, sinceBlock *string, done <-chan struct{},
+) (<-chan types.SyncEvent, error) {
+	addresses, err := s.deriveAccountAddresses(descriptors)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.Bus.ListSinceBlock(sinceBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.Bus.GetBlockChainInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan types.SyncEvent)
+	go func() {
+		defer close(ch)
+
+		// Resolving the inputs of outgoing transactions requires the cache.
+		txCache := s.Bus.NewTxCache()
+
+		emit := func(event types.SyncEvent) bool {
+			select {
+			case ch <- event:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		// A transaction may be listed several times, for ex: once per
+		// output, so it is emitted only once per category.
+		removed := make(map[string]bool)
+		for _, tx := range result.Removed {
+			if removed[tx.TxID] || !s.involvesAddresses(txCache, addresses, tx, info.Headers) {
+				continue
+			}
+
+			removed[tx.TxID] = true
+			if !emit(types.SyncEvent{TxHash: tx.TxID, Removed: true}) {
+				return
+			}
+		}
+
+		confirmed := make(map[string]bool)
+		for _, tx := range result.Transactions {
+			if tx.BlockHash == "" || confirmed[tx.TxID] ||
+				!s.involvesAddresses(txCache, addresses, tx, info.Headers) {
+				continue
+			}
+
+			confirmed[tx.TxID] = true
+			if !emit(types.SyncEvent{TxHash: tx.TxID, Block: blockFromTxResult(tx)}) {
+				return
+			}
+		}
+
+		emit(types.SyncEvent{LastBlock: result.LastBlock})
+	}()
+
+	return ch, nil
+}
+
+// deriveAccountAddresses derives syncAccountDepth addresses from each of the
+// passed descriptors.
+func (s *Service) deriveAccountAddresses(descriptors []string) ([]string, error) {
+	var addresses []string
+	for _, descriptor := range descriptors {
+		derived, err := utils.DeriveAddresses(descriptor, 0, syncAccountDepth, s.Bus.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, derived...)
+	}
+
+	return addresses, nil
+}
+
+// GetTransactionWalletOutputs is a service method to get the outputs of a
+// transaction that belong to the wallet, with their derivation paths.
+func (s *Service) GetTransactionWalletOutputs(hash string) ([]types.WalletOutput, error) {
+	tx, err := s.Bus.GetTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.walletOutputs([]*types.Transaction{tx})
+}
+
+// GetBlockWalletOutputs is a service method to get the outputs of the
+// transactions of a block, referenced by a string, that belong to the
+// wallet, with their derivation paths.
+func (s *Service) GetBlockWalletOutputs(ref string) ([]types.WalletOutput, error) {
+	rawBlockHash, err := s.getBlockHashByReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, err := s.Bus.GetBlockTransactions(rawBlockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.walletOutputs(txs)
+}
+
+// walletOutputs returns the outputs of the given transactions that belong to
+// the wallet, in transaction and output order. The derivation paths of all
+// the output addresses are resolved in a single batch.
+func (s *Service) walletOutputs(txs []*types.Transaction) ([]types.WalletOutput, error) {
+	var addresses []string
+	for _, tx := range txs {
+		for _, output := range tx.Outputs {
+			addresses = append(addresses, output.Address)
+		}
+	}
+
+	paths, err := s.Bus.GetDerivationPaths(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.WalletOutput{}
+	for _, tx := range txs {
+		for _, output := range tx.Outputs {
+			path, ok := paths[output.Address]
+			if !ok {
+				continue
+			}
+
+			result = append(result, types.WalletOutput{
+				TxHash:         tx.Hash,
+				OutputIndex:    *output.OutputIndex,
+				Value:          *output.Value,
+				Address:        output.Address,
+				DerivationPath: path,
+			})
+		}
+	}
+
+	return result, nil
+}
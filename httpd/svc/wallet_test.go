@@ -0,0 +1,179 @@
+package svc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ledgerhq/satstack/bus"
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestIsSafeToSpend(t *testing.T) {
+	const maturity = 100
+
+	tests := []struct {
+		name          string
+		confirmations int64
+		status        bus.OutputStatus
+		minConf       int64
+		want          bool
+	}{
+		{"confirmed", 1, bus.OutputStatus{}, 1, true},
+		{"unconfirmed", 0, bus.OutputStatus{}, 1, false},
+		{"unconfirmed with zero minConf", 0, bus.OutputStatus{}, 0, true},
+		{"below minConf", 5, bus.OutputStatus{}, 6, false},
+		{"at minConf", 6, bus.OutputStatus{}, 6, true},
+		{"immature coinbase", 99, bus.OutputStatus{Coinbase: true}, 1, false},
+		{"mature coinbase", 100, bus.OutputStatus{Coinbase: true}, 1, true},
+		{"locked", 10, bus.OutputStatus{Locked: true}, 1, false},
+		{"spent in mempool", 10, bus.OutputStatus{SpentInMempool: true}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utxo := types.UTXO{Confirmations: tt.confirmations}
+			if got := isSafeToSpend(utxo, tt.status, tt.minConf, maturity); got != tt.want {
+				t.Errorf("isSafeToSpend() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinConf(t *testing.T) {
+	if got := (&Service{}).minConf(); got != defaultMinConf {
+		t.Errorf("minConf() = %d, want the default %d", got, defaultMinConf)
+	}
+
+	if got := (&Service{MinConf: 6}).minConf(); got != 6 {
+		t.Errorf("minConf() = %d, want the configured 6", got)
+	}
+}
+
+func TestTruncateUTXOs(t *testing.T) {
+	const (
+		hashA = "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098"
+		hashB = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	)
+
+	utxos := []types.UTXO{
+		{OutputHash: hashB, OutputIndex: 0},
+		{OutputHash: hashA, OutputIndex: 2},
+		{OutputHash: hashB, OutputIndex: 1},
+		{OutputHash: hashA, OutputIndex: 0},
+	}
+
+	tests := []struct {
+		name          string
+		maxCount      int
+		wantTruncated bool
+		want          []types.UTXO
+	}{
+		{
+			name:          "more UTXOs than maxCount",
+			maxCount:      3,
+			wantTruncated: true,
+			want: []types.UTXO{
+				{OutputHash: hashA, OutputIndex: 0},
+				{OutputHash: hashA, OutputIndex: 2},
+				{OutputHash: hashB, OutputIndex: 0},
+			},
+		},
+		{
+			name:     "unlimited",
+			maxCount: 0,
+			want: []types.UTXO{
+				{OutputHash: hashA, OutputIndex: 0},
+				{OutputHash: hashA, OutputIndex: 2},
+				{OutputHash: hashB, OutputIndex: 0},
+				{OutputHash: hashB, OutputIndex: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]types.UTXO(nil), utxos...)
+
+			got := truncateUTXOs(input, tt.maxCount)
+			if got.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", got.Truncated, tt.wantTruncated)
+			}
+
+			if got.Total != len(utxos) {
+				t.Errorf("Total = %d, want %d", got.Total, len(utxos))
+			}
+
+			if !reflect.DeepEqual(got.UTXOs, tt.want) {
+				t.Errorf("UTXOs = %+v, want %+v", got.UTXOs, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateByScriptType(t *testing.T) {
+	p2wpkh := txscript.WitnessV0PubKeyHashTy.String()
+	p2pkh := txscript.PubKeyHashTy.String()
+
+	tests := []struct {
+		name  string
+		utxos []types.UTXO
+		want  map[string]types.ScriptTypeSummary
+	}{
+		{
+			name: "mixed script types",
+			utxos: []types.UTXO{
+				{ScriptType: p2wpkh, Value: 10000},
+				{ScriptType: p2pkh, Value: 5000},
+				{ScriptType: p2wpkh, Value: 25000},
+			},
+			want: map[string]types.ScriptTypeSummary{
+				p2wpkh: {Count: 2, Value: 35000},
+				p2pkh:  {Count: 1, Value: 5000},
+			},
+		},
+		{
+			name:  "no UTXOs",
+			utxos: nil,
+			want:  map[string]types.ScriptTypeSummary{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregateByScriptType(tt.utxos); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("aggregateByScriptType() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByScriptType(t *testing.T) {
+	p2wpkh := txscript.WitnessV0PubKeyHashTy.String()
+	p2pkh := txscript.PubKeyHashTy.String()
+
+	utxos := []types.UTXO{
+		{OutputIndex: 0, ScriptType: p2wpkh},
+		{OutputIndex: 1, ScriptType: p2pkh},
+		{OutputIndex: 2, ScriptType: p2wpkh},
+	}
+
+	tests := []struct {
+		name       string
+		scriptType string
+		want       []types.UTXO
+	}{
+		{"matching UTXOs in order", p2wpkh, []types.UTXO{utxos[0], utxos[2]}},
+		{"single match", p2pkh, []types.UTXO{utxos[1]}},
+		{"no match", txscript.ScriptHashTy.String(), []types.UTXO{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterByScriptType(utxos, tt.scriptType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterByScriptType() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
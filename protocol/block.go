@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BlockOutpoints returns the outpoints spent by the non-coinbase inputs of
+// the block transactions, and the outpoints created by their outputs, in
+// block order.
+//
+// Outputs that are created and spent within the same block appear in both
+// lists. Applying the created outpoints before the spent ones on a UTXO set
+// yields the correct state after the block.
+func BlockOutpoints(block *wire.MsgBlock) (spent []types.OutputIdentifier, created []types.OutputIdentifier) {
+	spent = []types.OutputIdentifier{}
+	created = []types.OutputIdentifier{}
+
+	for _, mtx := range block.Transactions {
+		if !blockchain.IsCoinBaseTx(mtx) {
+			for _, txIn := range mtx.TxIn {
+				spent = append(spent, types.OutputIdentifier{
+					Hash:  txIn.PreviousOutPoint.Hash.String(),
+					Index: txIn.PreviousOutPoint.Index,
+				})
+			}
+		}
+
+		hash := mtx.TxHash().String()
+		for i := range mtx.TxOut {
+			created = append(created, types.OutputIdentifier{
+				Hash:  hash,
+				Index: uint32(i),
+			})
+		}
+	}
+
+	return spent, created
+}
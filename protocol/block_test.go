@@ -0,0 +1,56 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestBlockOutpoints(t *testing.T) {
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		SignatureScript:  []byte{0x03, 0x40, 0x5f, 0x0a},
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	coinbase.AddTxOut(wire.NewTxOut(625000000, []byte{0x00, 0x14}))
+
+	// Spends an output created in an earlier block.
+	external, _ := chainhash.NewHashFromStr("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b")
+	parent := wire.NewMsgTx(wire.TxVersion)
+	parent.AddTxIn(wire.NewTxIn(wire.NewOutPoint(external, 3), nil, nil))
+	parent.AddTxOut(wire.NewTxOut(50000, []byte{0x00, 0x14}))
+	parent.AddTxOut(wire.NewTxOut(40000, []byte{0x00, 0x14}))
+
+	// Spends an output created earlier in the same block.
+	parentHash := parent.TxHash()
+	child := wire.NewMsgTx(wire.TxVersion)
+	child.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&parentHash, 1), nil, nil))
+	child.AddTxOut(wire.NewTxOut(30000, []byte{0x00, 0x14}))
+
+	block := &wire.MsgBlock{Transactions: []*wire.MsgTx{coinbase, parent, child}}
+
+	spent, created := BlockOutpoints(block)
+
+	wantSpent := []types.OutputIdentifier{
+		{Hash: external.String(), Index: 3},
+		{Hash: parentHash.String(), Index: 1},
+	}
+	if !reflect.DeepEqual(spent, wantSpent) {
+		t.Errorf("BlockOutpoints() spent = %+v, want %+v", spent, wantSpent)
+	}
+
+	wantCreated := []types.OutputIdentifier{
+		{Hash: coinbase.TxHash().String(), Index: 0},
+		{Hash: parentHash.String(), Index: 0},
+		{Hash: parentHash.String(), Index: 1},
+		{Hash: child.TxHash().String(), Index: 0},
+	}
+	if !reflect.DeepEqual(created, wantCreated) {
+		t.Errorf("BlockOutpoints() created = %+v, want %+v", created, wantCreated)
+	}
+}
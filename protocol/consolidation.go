@@ -0,0 +1,91 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// txOverheadWeight is the weight of the fields of a segwit transaction
+	// that do not belong to an input or an output: version, locktime, input
+	// and output counts (4 * (4 + 4 + 1 + 1)), plus the marker and flag.
+	txOverheadWeight = 42
+
+	// p2wpkhOutputWeight is the weight of a P2WPKH output: 8 bytes value,
+	// 1 byte script length, and a 22 bytes script.
+	p2wpkhOutputWeight = 4 * (8 + 1 + 22)
+)
+
+// spendWeights is the estimated weight of an input spending an output of
+// each script type, assuming a single 72 bytes ECDSA signature (64 bytes for
+// Schnorr), and a compressed public key.
+//
+// Outputs of type scripthash are assumed to be P2SH-P2WPKH, which is the only
+// P2SH script type supported by SatStack accounts.
+var spendWeights = map[string]int64{
+	txscript.PubKeyHashTy.String():          4 * 148, // 36 + 4 + 1 + 107 (sig + pubkey)
+	txscript.ScriptHashTy.String():          4*64 + 108,
+	txscript.WitnessV0PubKeyHashTy.String(): 4*41 + 108,
+	ScriptTaproot:                           4*41 + 66,
+}
+
+// ConsolidationSavings estimates the fees saved by consolidating the given
+// UTXOs into a single P2WPKH output at the current fee rate, instead of
+// spending them individually later at the assumed future fee rate. Fee rates
+// are in satoshis per kvB.
+//
+// The savings are negative if consolidating is not worth it. The break-even
+// fee rate is the future fee rate above which consolidating now saves fees.
+// It is nil if there is nothing to save, for ex: with a single UTXO.
+func ConsolidationSavings(
+	utxos []types.UTXO, currentFeeRate btcutil.Amount, futureFeeRate btcutil.Amount,
+) (*types.ConsolidationEstimate, error) {
+	var inputsWeight int64
+	for _, utxo := range utxos {
+		weight, ok := spendWeights[utxo.ScriptType]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedScriptType, utxo.ScriptType)
+		}
+
+		inputsWeight += weight
+	}
+
+	consolidationVSize := weightToVSize(txOverheadWeight + inputsWeight + p2wpkhOutputWeight)
+	inputsVSize := weightToVSize(inputsWeight)
+	consolidatedInputVSize := weightToVSize(spendWeights[txscript.WitnessV0PubKeyHashTy.String()])
+
+	estimate := types.ConsolidationEstimate{
+		ConsolidationVSize:    consolidationVSize,
+		ConsolidationFee:      feeForVSize(consolidationVSize, currentFeeRate),
+		FutureFeeIndividual:   feeForVSize(inputsVSize, futureFeeRate),
+		FutureFeeConsolidated: feeForVSize(consolidatedInputVSize, futureFeeRate),
+	}
+
+	estimate.Savings = estimate.FutureFeeIndividual -
+		estimate.FutureFeeConsolidated - estimate.ConsolidationFee
+
+	// Solve ConsolidationFee = (inputsVSize - consolidatedInputVSize) * rate
+	// for the future fee rate.
+	if savedVSize := inputsVSize - consolidatedInputVSize; savedVSize > 0 {
+		breakEven := estimate.ConsolidationFee * 1000 / btcutil.Amount(savedVSize)
+		estimate.BreakEvenFeeRate = &breakEven
+	}
+
+	return &estimate, nil
+}
+
+// weightToVSize converts a weight to virtual bytes, rounding up.
+func weightToVSize(weight int64) int64 {
+	return (weight + blockchain.WitnessScaleFactor - 1) / blockchain.WitnessScaleFactor
+}
+
+// feeForVSize returns the fee paid by vsize virtual bytes at the given fee
+// rate, in satoshis per kvB.
+func feeForVSize(vsize int64, feeRate btcutil.Amount) btcutil.Amount {
+	return btcutil.Amount(vsize) * feeRate / 1000
+}
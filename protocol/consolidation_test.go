@@ -0,0 +1,109 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestConsolidationSavings(t *testing.T) {
+	p2wpkh := types.UTXO{ScriptType: txscript.WitnessV0PubKeyHashTy.String()}
+	p2pkh := types.UTXO{ScriptType: txscript.PubKeyHashTy.String()}
+
+	tests := []struct {
+		name          string
+		utxos         []types.UTXO
+		currentRate   btcutil.Amount
+		futureRate    btcutil.Amount
+		wantVSize     int64
+		wantFee       btcutil.Amount
+		wantSavings   btcutil.Amount
+		wantBreakEven *btcutil.Amount
+	}{
+		{
+			name:          "two P2WPKH, fees rising",
+			utxos:         []types.UTXO{p2wpkh, p2wpkh},
+			currentRate:   1000,
+			futureRate:    10000,
+			wantVSize:     178, // (42 + 2*272 + 124) / 4, rounded up
+			wantFee:       178,
+			wantSavings:   1360 - 680 - 178,
+			wantBreakEven: amount(2617), // 178 * 1000 / (136 - 68)
+		},
+		{
+			name:          "two P2WPKH, fees falling",
+			utxos:         []types.UTXO{p2wpkh, p2wpkh},
+			currentRate:   10000,
+			futureRate:    1000,
+			wantVSize:     178,
+			wantFee:       1780,
+			wantSavings:   136 - 68 - 1780,
+			wantBreakEven: amount(26176),
+		},
+		{
+			name:        "single UTXO",
+			utxos:       []types.UTXO{p2wpkh},
+			currentRate: 1000,
+			futureRate:  10000,
+			wantVSize:   110,
+			wantFee:     110,
+			wantSavings: -110,
+		},
+		{
+			name:          "legacy UTXOs",
+			utxos:         []types.UTXO{p2pkh, p2pkh, p2pkh},
+			currentRate:   1000,
+			futureRate:    1000,
+			wantVSize:     486, // (42 + 3*592 + 124) / 4, rounded up
+			wantFee:       486,
+			wantSavings:   444 - 68 - 486,
+			wantBreakEven: amount(1292), // 486 * 1000 / (444 - 68)
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConsolidationSavings(tt.utxos, tt.currentRate, tt.futureRate)
+			if err != nil {
+				t.Fatalf("ConsolidationSavings() error = %v", err)
+			}
+
+			if got.ConsolidationVSize != tt.wantVSize {
+				t.Errorf("ConsolidationVSize = %d, want %d", got.ConsolidationVSize, tt.wantVSize)
+			}
+
+			if got.ConsolidationFee != tt.wantFee {
+				t.Errorf("ConsolidationFee = %d, want %d", got.ConsolidationFee, tt.wantFee)
+			}
+
+			if got.Savings != tt.wantSavings {
+				t.Errorf("Savings = %d, want %d", got.Savings, tt.wantSavings)
+			}
+
+			switch {
+			case got.BreakEvenFeeRate == nil && tt.wantBreakEven == nil:
+			case got.BreakEvenFeeRate == nil || tt.wantBreakEven == nil:
+				t.Errorf("BreakEvenFeeRate = %v, want %v", got.BreakEvenFeeRate, tt.wantBreakEven)
+			case *got.BreakEvenFeeRate != *tt.wantBreakEven:
+				t.Errorf("BreakEvenFeeRate = %d, want %d", *got.BreakEvenFeeRate, *tt.wantBreakEven)
+			}
+		})
+	}
+}
+
+func TestConsolidationSavingsUnsupportedScript(t *testing.T) {
+	utxos := []types.UTXO{{ScriptType: txscript.MultiSigTy.String()}}
+
+	_, err := ConsolidationSavings(utxos, 1000, 1000)
+	if !errors.Is(err, ErrUnsupportedScriptType) {
+		t.Errorf("ConsolidationSavings() error = %v, want %v", err, ErrUnsupportedScriptType)
+	}
+}
+
+func amount(value btcutil.Amount) *btcutil.Amount {
+	return &value
+}
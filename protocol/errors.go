@@ -9,4 +9,8 @@ var (
 	// ErrMsgTxDeserialize indicates that the parser could not process the
 	// serialized hex to wire.MsgTx.
 	ErrMsgTxDeserialize = errors.New("failed to deserialize to MsgTx")
+
+	// ErrUnsupportedScriptType indicates that the size of an input spending
+	// an output of the given script type cannot be estimated.
+	ErrUnsupportedScriptType = errors.New("unsupported script type")
 )
@@ -0,0 +1,34 @@
+package protocol
+
+import (
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// FeeRateForVSize returns the fee rate of a transaction paying the given fee
+// for vsize virtual bytes. It returns nil if the vsize is not positive.
+func FeeRateForVSize(fee btcutil.Amount, vsize int64) *types.FeeRate {
+	if vsize <= 0 {
+		return nil
+	}
+
+	feeRate := newFeeRate(float64(fee) / float64(vsize))
+	return &feeRate
+}
+
+// FeeRateFromKvB converts a fee rate in satoshis per kvB, for ex: as returned
+// by the estimatesmartfee RPC, to a types.FeeRate.
+func FeeRateFromKvB(feeRate btcutil.Amount) types.FeeRate {
+	return newFeeRate(float64(feeRate) / 1000)
+}
+
+// newFeeRate derives every representation of a fee rate from the same value
+// in satoshis per vB, so that they are consistent with each other.
+func newFeeRate(satPerVByte float64) types.FeeRate {
+	return types.FeeRate{
+		SatPerVByte: satPerVByte,
+		SatPerKvB:   satPerVByte * 1000,
+		BTCPerKB:    satPerVByte * 1000 / btcutil.SatoshiPerBitcoin,
+	}
+}
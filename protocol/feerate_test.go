@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcutil"
+)
+
+func TestFeeRateForVSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		fee   btcutil.Amount
+		vsize int64
+		want  *types.FeeRate
+	}{
+		{
+			name:  "whole rate",
+			fee:   2820,
+			vsize: 141,
+			want:  &types.FeeRate{SatPerVByte: 20, SatPerKvB: 20000, BTCPerKB: 0.0002},
+		},
+		{
+			name:  "fractional rate",
+			fee:   250,
+			vsize: 200,
+			want:  &types.FeeRate{SatPerVByte: 1.25, SatPerKvB: 1250, BTCPerKB: 0.0000125},
+		},
+		{
+			name:  "zero fee",
+			fee:   0,
+			vsize: 141,
+			want:  &types.FeeRate{},
+		},
+		{"zero vsize", 1000, 0, nil},
+		{"negative vsize", 1000, -1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FeeRateForVSize(tt.fee, tt.vsize); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FeeRateForVSize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeeRateFromKvB(t *testing.T) {
+	tests := []struct {
+		feeRate btcutil.Amount
+		want    types.FeeRate
+	}{
+		{1000, types.FeeRate{SatPerVByte: 1, SatPerKvB: 1000, BTCPerKB: 0.00001}},
+		{12345, types.FeeRate{SatPerVByte: 12.345, SatPerKvB: 12345, BTCPerKB: 0.00012345}},
+		{0, types.FeeRate{}},
+	}
+
+	for _, tt := range tests {
+		if got := FeeRateFromKvB(tt.feeRate); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FeeRateFromKvB(%d) = %+v, want %+v", tt.feeRate, got, tt.want)
+		}
+	}
+}
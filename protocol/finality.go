@@ -0,0 +1,107 @@
+package protocol
+
+import (
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// PrevoutConfirmation models the block confirming the output spent by an
+// input, as required to evaluate its BIP-0068 relative lock time.
+type PrevoutConfirmation struct {
+	// Height of the block including the transaction of the output.
+	Height int64
+
+	// MedianTimePast of the parent of the block, i.e., the time from which
+	// time-based relative lock times are counted.
+	MedianTimePast int64
+}
+
+// HasRelativeLock reports whether the input of the transaction is subject to
+// a BIP-0068 relative lock time, which is only enforced for transactions of
+// version 2 or more.
+func HasRelativeLock(mtx *wire.MsgTx, txIn *wire.TxIn) bool {
+	return mtx.Version >= 2 && txIn.Sequence&wire.SequenceLockTimeDisabled == 0
+}
+
+// TxFinality reports whether the transaction can be included in the next
+// block, given the height and median time past of the current tip.
+//
+// The absolute lock time (nLockTime) is satisfied if the height of the next
+// block, or the median time past of the tip (BIP-0113), is above it. It is
+// disabled if all the inputs have the maximum sequence number.
+//
+// The relative lock time (BIP-0068) of each input is counted from the block
+// confirming the output it spends, given in prevouts at the same index. A nil
+// item means that the output is unconfirmed, in which case the relative lock
+// time cannot be satisfied yet, and the earliest final height is unknown.
+//
+// If the transaction is not final, MinHeight is the height of the first
+// block that may include it, and MinMedianTimePast is the median time past
+// that the parent of this block must reach, if time-locked.
+func TxFinality(
+	mtx *wire.MsgTx, tipHeight int64, tipMedianTimePast int64, prevouts []*PrevoutConfirmation,
+) *types.Finality {
+	var minHeight, minTime int64
+	var unconfirmedPrevouts bool
+
+	if mtx.LockTime != 0 && !allInputsFinal(mtx) {
+		if mtx.LockTime < txscript.LockTimeThreshold {
+			minHeight = int64(mtx.LockTime) + 1
+		} else {
+			minTime = int64(mtx.LockTime) + 1
+		}
+	}
+
+	for i, txIn := range mtx.TxIn {
+		if !HasRelativeLock(mtx, txIn) {
+			continue
+		}
+
+		prevout := prevouts[i]
+		if prevout == nil {
+			unconfirmedPrevouts = true
+			continue
+		}
+
+		value := int64(txIn.Sequence & wire.SequenceLockTimeMask)
+
+		if txIn.Sequence&wire.SequenceLockTimeIsSeconds != 0 {
+			lockTime := prevout.MedianTimePast + value<<wire.SequenceLockTimeGranularity
+			if lockTime > minTime {
+				minTime = lockTime
+			}
+		} else if lockTime := prevout.Height + value; lockTime > minHeight {
+			minHeight = lockTime
+		}
+	}
+
+	finality := types.Finality{
+		Final: !unconfirmedPrevouts &&
+			minHeight <= tipHeight+1 && minTime <= tipMedianTimePast,
+		UnconfirmedPrevouts: unconfirmedPrevouts,
+	}
+
+	if minHeight > tipHeight+1 {
+		finality.MinHeight = &minHeight
+	}
+
+	if minTime > tipMedianTimePast {
+		finality.MinMedianTimePast = &minTime
+	}
+
+	return &finality
+}
+
+// allInputsFinal reports whether all the inputs of the transaction have the
+// maximum sequence number, which disables the absolute lock time.
+func allInputsFinal(mtx *wire.MsgTx) bool {
+	for _, txIn := range mtx.TxIn {
+		if txIn.Sequence != wire.MaxTxInSequenceNum {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,136 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestTxFinality(t *testing.T) {
+	const (
+		tipHeight = 700000
+		tipMTP    = 1630000000
+	)
+
+	int64Ptr := func(v int64) *int64 {
+		return &v
+	}
+
+	// finalityTx returns a transaction with one input per sequence number.
+	finalityTx := func(version int32, lockTime uint32, sequences ...uint32) *wire.MsgTx {
+		mtx := wire.NewMsgTx(version)
+		mtx.LockTime = lockTime
+		for _, sequence := range sequences {
+			mtx.AddTxIn(&wire.TxIn{Sequence: sequence})
+		}
+
+		return mtx
+	}
+
+	confirmed := &PrevoutConfirmation{Height: tipHeight - 10, MedianTimePast: tipMTP - 3000}
+
+	tests := []struct {
+		name     string
+		mtx      *wire.MsgTx
+		prevouts []*PrevoutConfirmation
+		want     types.Finality
+	}{
+		{
+			name:     "no lock time",
+			mtx:      finalityTx(1, 0, wire.MaxTxInSequenceNum),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{Final: true},
+		},
+		{
+			name:     "height lock time at the tip",
+			mtx:      finalityTx(1, tipHeight, 0xfffffffe),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{Final: true},
+		},
+		{
+			name:     "height lock time at the next block",
+			mtx:      finalityTx(1, tipHeight+1, 0xfffffffe),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{MinHeight: int64Ptr(tipHeight + 2)},
+		},
+		{
+			name:     "height lock time disabled by final sequences",
+			mtx:      finalityTx(1, tipHeight+100, wire.MaxTxInSequenceNum),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{Final: true},
+		},
+		{
+			name:     "time lock time below the median time past",
+			mtx:      finalityTx(1, tipMTP-1, 0xfffffffe),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{Final: true},
+		},
+		{
+			name:     "time lock time at the median time past",
+			mtx:      finalityTx(1, tipMTP, 0xfffffffe),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{MinMedianTimePast: int64Ptr(tipMTP + 1)},
+		},
+		{
+			name:     "relative height lock satisfied",
+			mtx:      finalityTx(2, 0, 11),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{Final: true},
+		},
+		{
+			name:     "relative height lock not satisfied",
+			mtx:      finalityTx(2, 0, 12),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{MinHeight: int64Ptr(tipHeight + 2)},
+		},
+		{
+			name:     "relative height lock ignored before version 2",
+			mtx:      finalityTx(1, 0, 100),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{Final: true},
+		},
+		{
+			name:     "relative lock disabled by the sequence flag",
+			mtx:      finalityTx(2, 0, wire.SequenceLockTimeDisabled|100),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{Final: true},
+		},
+		{
+			// 6 units of 512 seconds, i.e., 3072 seconds.
+			name:     "relative time lock not satisfied",
+			mtx:      finalityTx(2, 0, wire.SequenceLockTimeIsSeconds|6),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{MinMedianTimePast: int64Ptr(tipMTP + 72)},
+		},
+		{
+			name:     "relative time lock satisfied",
+			mtx:      finalityTx(2, 0, wire.SequenceLockTimeIsSeconds|5),
+			prevouts: []*PrevoutConfirmation{confirmed},
+			want:     types.Finality{Final: true},
+		},
+		{
+			name:     "relative lock on an unconfirmed prevout",
+			mtx:      finalityTx(2, 0, wire.MaxTxInSequenceNum, 1),
+			prevouts: []*PrevoutConfirmation{nil, nil},
+			want:     types.Finality{UnconfirmedPrevouts: true},
+		},
+		{
+			name:     "latest of the absolute and relative locks",
+			mtx:      finalityTx(2, tipHeight+5, 20, 0xfffffffe),
+			prevouts: []*PrevoutConfirmation{confirmed, confirmed},
+			want:     types.Finality{MinHeight: int64Ptr(tipHeight + 10)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TxFinality(tt.mtx, tipHeight, tipMTP, tt.prevouts)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("TxFinality() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
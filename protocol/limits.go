@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// MaxStandardTxWeight is the maximum weight of a transaction relayed by
+// Bitcoin Core nodes with the default policy. Heavier transactions are
+// rejected with the "tx-size" reason.
+const MaxStandardTxWeight = 400000
+
+// SizeLimitsForWeight reports whether a transaction of the given weight is
+// within the standardness and consensus limits.
+//
+// Only the weight is known here, so the consensus limit on the size of the
+// transaction without witness data is not checked. Use SizeLimits with the
+// full transaction instead, if available.
+func SizeLimitsForWeight(weight int64) types.SizeLimits {
+	return types.SizeLimits{
+		Weight:          weight,
+		VSize:           weightToVSize(weight),
+		WithinStandard:  weight <= MaxStandardTxWeight,
+		WithinConsensus: weight <= blockchain.MaxBlockWeight,
+	}
+}
+
+// SizeLimits reports whether the transaction is within the standardness and
+// consensus limits.
+//
+// By consensus, a transaction must fit in a block, and its size without
+// witness data, scaled by the witness factor, must not exceed the maximum
+// block weight (bad-txns-oversize).
+func SizeLimits(mtx *wire.MsgTx) types.SizeLimits {
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(mtx))
+	limits := SizeLimitsForWeight(weight)
+
+	strippedWeight := int64(mtx.SerializeSizeStripped()) * blockchain.WitnessScaleFactor
+	if strippedWeight > blockchain.MaxBlockWeight {
+		limits.WithinConsensus = false
+	}
+
+	return limits
+}
@@ -0,0 +1,84 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSizeLimitsForWeight(t *testing.T) {
+	tests := []struct {
+		weight int64
+		want   types.SizeLimits
+	}{
+		{561, types.SizeLimits{Weight: 561, VSize: 141, WithinStandard: true, WithinConsensus: true}},
+		{MaxStandardTxWeight, types.SizeLimits{
+			Weight: MaxStandardTxWeight, VSize: 100000, WithinStandard: true, WithinConsensus: true,
+		}},
+		{MaxStandardTxWeight + 1, types.SizeLimits{
+			Weight: MaxStandardTxWeight + 1, VSize: 100001, WithinStandard: false, WithinConsensus: true,
+		}},
+		{blockchain.MaxBlockWeight, types.SizeLimits{
+			Weight: blockchain.MaxBlockWeight, VSize: 1000000, WithinStandard: false, WithinConsensus: true,
+		}},
+		{blockchain.MaxBlockWeight + 1, types.SizeLimits{
+			Weight: blockchain.MaxBlockWeight + 1, VSize: 1000001, WithinStandard: false, WithinConsensus: false,
+		}},
+	}
+
+	for _, tt := range tests {
+		if got := SizeLimitsForWeight(tt.weight); got != tt.want {
+			t.Errorf("SizeLimitsForWeight(%d) = %+v, want %+v", tt.weight, got, tt.want)
+		}
+	}
+}
+
+func TestSizeLimits(t *testing.T) {
+	// newTx returns a transaction with a single input, and an output with
+	// a script of the given size.
+	newTx := func(scriptSize int, witnessSize int) *wire.MsgTx {
+		mtx := wire.NewMsgTx(wire.TxVersion)
+		mtx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Hash: chainhash.Hash{0x01}},
+		})
+
+		if witnessSize > 0 {
+			mtx.TxIn[0].Witness = wire.TxWitness{make([]byte, witnessSize)}
+		}
+
+		mtx.AddTxOut(wire.NewTxOut(1000, make([]byte, scriptSize)))
+		return mtx
+	}
+
+	tests := []struct {
+		name                string
+		mtx                 *wire.MsgTx
+		wantWithinStandard  bool
+		wantWithinConsensus bool
+	}{
+		{"small", newTx(22, 107), true, true},
+		{"non-standard", newTx(100000, 0), false, true},
+		{"witness-heavy, below consensus", newTx(22, 3000000), false, true},
+		{"oversize", newTx(1000001, 0), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SizeLimits(tt.mtx)
+
+			if got.WithinStandard != tt.wantWithinStandard {
+				t.Errorf("WithinStandard = %v, want %v (weight %d)",
+					got.WithinStandard, tt.wantWithinStandard, got.Weight)
+			}
+
+			if got.WithinConsensus != tt.wantWithinConsensus {
+				t.Errorf("WithinConsensus = %v, want %v (weight %d)",
+					got.WithinConsensus, tt.wantWithinConsensus, got.Weight)
+			}
+		})
+	}
+}
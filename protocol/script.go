@@ -0,0 +1,36 @@
+package protocol
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// ScriptTaproot is the script type of a segwit v1 output paying to a taproot
+// output key, named after the type reported by Bitcoin Core.
+//
+// It is not known by the version of btcd used by SatStack, which classifies
+// such outputs as witness_unknown.
+const ScriptTaproot = "witness_v1_taproot"
+
+// ScriptType classifies a hex-encoded output script, and returns its type
+// using the same names as Bitcoin Core. For example: pubkeyhash, scripthash,
+// witness_v0_keyhash, witness_v0_scripthash, witness_v1_taproot or nulldata.
+//
+// Scripts that cannot be decoded are reported as nonstandard.
+func ScriptType(scriptHex string) string {
+	script, err := hex.DecodeString(scriptHex)
+	if err != nil {
+		return txscript.NonStandardTy.String()
+	}
+
+	class := txscript.GetScriptClass(script)
+	if class == txscript.WitnessUnknownTy {
+		version, program, err := txscript.ExtractWitnessProgramInfo(script)
+		if err == nil && version == 1 && len(program) == 32 {
+			return ScriptTaproot
+		}
+	}
+
+	return class.String()
+}
@@ -0,0 +1,167 @@
+package protocol
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// sigHashDefault is the BIP-0341 sighash type of a Schnorr signature
+	// that is exactly 64 bytes long. It has the same semantics as
+	// SIGHASH_ALL, but the flag byte is omitted from the signature.
+	sigHashDefault txscript.SigHashType = 0x00
+
+	// schnorrSigLen is the length of a BIP-0340 Schnorr signature, without
+	// the trailing sighash flag byte.
+	schnorrSigLen = 64
+
+	// taprootAnnexTag is the first byte of the optional annex, which is the
+	// last element of a taproot witness stack.
+	taprootAnnexTag = 0x50
+)
+
+// SigHashTypes returns the sighash types of the signatures found in each
+// input of the transaction, in input order.
+//
+// ECDSA signatures are looked up in both the signature script and the witness
+// data, and are identified by their strict DER encoding. The sighash flag is
+// the byte appended to the DER-encoded signature.
+//
+// Taproot inputs have an empty signature script, and carry 64 or 65 bytes long
+// Schnorr signatures in the witness. A 64 bytes signature implies
+// SIGHASH_DEFAULT, otherwise the flag is the 65th byte. Schnorr signatures
+// are only looked up in:
+//   - key path spends, where the signature is the only witness element,
+//   - script path spends, in the stack elements preceding the script and the
+//     control block.
+//
+// Other witness elements of 64 or 65 bytes, for ex: control blocks or
+// uncompressed public keys, are not signatures.
+//
+// Coinbase inputs, and inputs without any recognizable signature, have an
+// empty list of sighash types.
+func SigHashTypes(mtx *wire.MsgTx) [][]txscript.SigHashType {
+	result := make([][]txscript.SigHashType, len(mtx.TxIn))
+
+	for i, txIn := range mtx.TxIn {
+		var hashTypes []txscript.SigHashType
+
+		// Ignore the error here, since a script that fails to parse
+		// cannot contain any signature that we're able to recognize.
+		pushes, _ := txscript.PushedData(txIn.SignatureScript)
+		for _, push := range pushes {
+			if isStrictDERSignature(push) {
+				hashTypes = append(hashTypes, txscript.SigHashType(push[len(push)-1]))
+			}
+		}
+
+		witness := stripAnnex(txIn.Witness)
+
+		switch {
+		case len(txIn.SignatureScript) == 0 && len(witness) == 1 &&
+			isSchnorrSignature(witness[0]):
+			// Taproot key path spend
+			hashTypes = append(hashTypes, schnorrSigHashType(witness[0]))
+
+		case len(txIn.SignatureScript) == 0 && len(witness) >= 2 &&
+			isTaprootControlBlock(witness[len(witness)-1]):
+			// Taproot script path spend
+			for _, item := range witness[:len(witness)-2] {
+				if isSchnorrSignature(item) {
+					hashTypes = append(hashTypes, schnorrSigHashType(item))
+				}
+			}
+
+		default:
+			for _, item := range witness {
+				if isStrictDERSignature(item) {
+					hashTypes = append(hashTypes, txscript.SigHashType(item[len(item)-1]))
+				}
+			}
+		}
+
+		result[i] = hashTypes
+	}
+
+	return result
+}
+
+// SigHashName returns a human-readable representation of a sighash type,
+// for ex: ALL, NONE|ANYONECANPAY or DEFAULT.
+func SigHashName(hashType txscript.SigHashType) string {
+	if hashType == sigHashDefault {
+		return "DEFAULT"
+	}
+
+	var name string
+	switch hashType &^ txscript.SigHashAnyOneCanPay {
+	case txscript.SigHashAll:
+		name = "ALL"
+	case txscript.SigHashNone:
+		name = "NONE"
+	case txscript.SigHashSingle:
+		name = "SINGLE"
+	default:
+		name = "UNKNOWN"
+	}
+
+	if hashType&txscript.SigHashAnyOneCanPay != 0 {
+		name += "|ANYONECANPAY"
+	}
+
+	return name
+}
+
+// isSchnorrSignature checks if a witness element has the length of a
+// BIP-0340 Schnorr signature, with or without the sighash flag byte.
+func isSchnorrSignature(item []byte) bool {
+	return len(item) == schnorrSigLen || len(item) == schnorrSigLen+1
+}
+
+// schnorrSigHashType returns the sighash type of a Schnorr signature.
+func schnorrSigHashType(sig []byte) txscript.SigHashType {
+	if len(sig) == schnorrSigLen {
+		return sigHashDefault
+	}
+
+	return txscript.SigHashType(sig[schnorrSigLen])
+}
+
+// stripAnnex removes the annex from a taproot witness stack, if present.
+//
+// Ref: https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki
+func stripAnnex(witness wire.TxWitness) wire.TxWitness {
+	if n := len(witness); n >= 2 && len(witness[n-1]) > 0 &&
+		witness[n-1][0] == taprootAnnexTag {
+		return witness[:n-1]
+	}
+
+	return witness
+}
+
+// isStrictDERSignature checks if the passed data is a DER-encoded ECDSA
+// signature, followed by a sighash flag byte, according to the rules of
+// BIP-0066.
+//
+// Format: 0x30 [total-length] 0x02 [R-length] [R] 0x02 [S-length] [S] [sighash]
+func isStrictDERSignature(sig []byte) bool {
+	if len(sig) < 9 || len(sig) > 73 {
+		return false
+	}
+
+	if sig[0] != 0x30 || int(sig[1]) != len(sig)-3 {
+		return false
+	}
+
+	rLen := int(sig[3])
+	if sig[2] != 0x02 || rLen == 0 || 5+rLen >= len(sig) {
+		return false
+	}
+
+	sLen := int(sig[5+rLen])
+	if sig[4+rLen] != 0x02 || sLen == 0 || rLen+sLen+7 != len(sig) {
+		return false
+	}
+
+	return true
+}
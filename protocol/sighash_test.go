@@ -0,0 +1,150 @@
+package protocol
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// derSignature returns a DER-encoded ECDSA signature, with 32 bytes long r
+// and s values, followed by the sighash flag byte.
+func derSignature(hashType txscript.SigHashType) []byte {
+	sig := []byte{0x30, 0x44, 0x02, 0x20}
+	sig = append(sig, bytes.Repeat([]byte{0x01}, 32)...)
+	sig = append(sig, 0x02, 0x20)
+	sig = append(sig, bytes.Repeat([]byte{0x02}, 32)...)
+	return append(sig, byte(hashType))
+}
+
+// schnorrSignature returns a Schnorr signature, with a trailing sighash flag
+// byte unless the sighash type is SIGHASH_DEFAULT.
+func schnorrSignature(hashType txscript.SigHashType) []byte {
+	sig := bytes.Repeat([]byte{0x03}, schnorrSigLen)
+	if hashType == sigHashDefault {
+		return sig
+	}
+
+	return append(sig, byte(hashType))
+}
+
+// controlBlock returns a taproot control block with a single merkle path
+// node, which makes it 65 bytes long.
+func controlBlock() []byte {
+	block := []byte{taprootLeafTapscript}
+	block = append(block, bytes.Repeat([]byte{0x04}, 32)...)
+	return append(block, bytes.Repeat([]byte{0x05}, 32)...)
+}
+
+func TestSigHashTypes(t *testing.T) {
+	uncompressedPubKey := append([]byte{0x04}, bytes.Repeat([]byte{0x06}, 64)...)
+	tapscript := []byte{txscript.OP_TRUE}
+	annex := []byte{taprootAnnexTag, 0x00}
+
+	tests := []struct {
+		name            string
+		signatureScript []byte
+		witness         wire.TxWitness
+		want            []txscript.SigHashType
+	}{
+		{
+			name:    "taproot key path with default sighash",
+			witness: wire.TxWitness{schnorrSignature(sigHashDefault)},
+			want:    []txscript.SigHashType{sigHashDefault},
+		},
+		{
+			name: "taproot key path with explicit sighash and annex",
+			witness: wire.TxWitness{
+				schnorrSignature(txscript.SigHashSingle | txscript.SigHashAnyOneCanPay),
+				annex,
+			},
+			want: []txscript.SigHashType{txscript.SigHashSingle | txscript.SigHashAnyOneCanPay},
+		},
+		{
+			name: "taproot script path ignores the 65 bytes control block",
+			witness: wire.TxWitness{
+				schnorrSignature(txscript.SigHashNone),
+				schnorrSignature(sigHashDefault),
+				tapscript,
+				controlBlock(),
+			},
+			want: []txscript.SigHashType{txscript.SigHashNone, sigHashDefault},
+		},
+		{
+			name:    "taproot script path without signature",
+			witness: wire.TxWitness{tapscript, controlBlock()},
+			want:    nil,
+		},
+		{
+			name: "P2WSH with a 65 bytes uncompressed public key",
+			witness: wire.TxWitness{
+				{},
+				derSignature(txscript.SigHashAll),
+				uncompressedPubKey,
+				tapscript,
+			},
+			want: []txscript.SigHashType{txscript.SigHashAll},
+		},
+		{
+			name: "P2WPKH with non-default sighash",
+			witness: wire.TxWitness{
+				derSignature(txscript.SigHashAll | txscript.SigHashAnyOneCanPay),
+				bytes.Repeat([]byte{0x02}, 33),
+			},
+			want: []txscript.SigHashType{txscript.SigHashAll | txscript.SigHashAnyOneCanPay},
+		},
+		{
+			name: "P2PKH signature script with uncompressed public key",
+			signatureScript: func() []byte {
+				script, _ := txscript.NewScriptBuilder().
+					AddData(derSignature(txscript.SigHashSingle)).
+					AddData(uncompressedPubKey).
+					Script()
+				return script
+			}(),
+			want: []txscript.SigHashType{txscript.SigHashSingle},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mtx := wire.NewMsgTx(wire.TxVersion)
+			mtx.AddTxIn(&wire.TxIn{
+				SignatureScript: tt.signatureScript,
+				Witness:         tt.witness,
+			})
+
+			got := SigHashTypes(mtx)
+			if len(got) != 1 {
+				t.Fatalf("SigHashTypes() returned %d inputs, want 1", len(got))
+			}
+
+			if !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("SigHashTypes() = %v, want %v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestSigHashName(t *testing.T) {
+	tests := []struct {
+		hashType txscript.SigHashType
+		want     string
+	}{
+		{sigHashDefault, "DEFAULT"},
+		{txscript.SigHashAll, "ALL"},
+		{txscript.SigHashNone, "NONE"},
+		{txscript.SigHashSingle, "SINGLE"},
+		{txscript.SigHashAll | txscript.SigHashAnyOneCanPay, "ALL|ANYONECANPAY"},
+		{txscript.SigHashSingle | txscript.SigHashAnyOneCanPay, "SINGLE|ANYONECANPAY"},
+		{0x04, "UNKNOWN"},
+	}
+
+	for _, tt := range tests {
+		if got := SigHashName(tt.hashType); got != tt.want {
+			t.Errorf("SigHashName(%#x) = %q, want %q", uint32(tt.hashType), got, tt.want)
+		}
+	}
+}
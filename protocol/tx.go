@@ -16,7 +16,10 @@ import (
 	"github.com/ledgerhq/satstack/types"
 )
 
-func ParseVerboseTransaction(txRaw *btcjson.TxRawResult) *types.Transaction {
+// ParseVerboseTransaction converts the result of the verbose
+// getrawtransaction RPC to a types.Transaction. Output values are converted
+// to the smallest unit of a currency with the given number of decimals.
+func ParseVerboseTransaction(txRaw *btcjson.TxRawResult, decimals int) *types.Transaction {
 	var inputs []types.Input
 	for i, input := range txRaw.Vin {
 		var scriptSig *string
@@ -37,7 +40,7 @@ func ParseVerboseTransaction(txRaw *btcjson.TxRawResult) *types.Transaction {
 
 	var outputs []types.Output
 	for _, output := range txRaw.Vout {
-		val := utils.ParseSatoshi(output.Value)
+		val := utils.ParseSmallestUnit(output.Value, decimals)
 		var addr string
 		if addrs := output.ScriptPubKey.Addresses; len(addrs) > 0 {
 			addr = addrs[0]
@@ -60,17 +63,54 @@ func ParseVerboseTransaction(txRaw *btcjson.TxRawResult) *types.Transaction {
 	}
 }
 
+// DecodeMsgTx converts a wire.MsgTx to a types.Transaction.
+//
+// The size, vsize and weight are computed from the transaction itself,
+// rather than taken from the RPC response, since the gettransaction RPC of
+// the wallet does not report them.
 func DecodeMsgTx(msgTx *wire.MsgTx, params *chaincfg.Params) *types.Transaction {
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(msgTx))
+
 	return &types.Transaction{
 		ID:       msgTx.TxHash().String(),
 		Hash:     msgTx.TxHash().String(),
 		LockTime: msgTx.LockTime,
+		Size:     int64(msgTx.SerializeSize()),
+		VSize:    weightToVSize(weight),
+		Weight:   weight,
 		Inputs:   createVinList(msgTx),
 		Outputs:  createVoutList(msgTx, params),
 	}
 }
 
+// SummarizeMsgTx returns the input and output counts, and the total output
+// value of a transaction, without building the full list of inputs and
+// outputs.
+func SummarizeMsgTx(msgTx *wire.MsgTx) *types.TransactionSummary {
+	var total btcutil.Amount
+	for _, txOut := range msgTx.TxOut {
+		total += btcutil.Amount(txOut.Value)
+	}
+
+	return &types.TransactionSummary{
+		Hash:             msgTx.TxHash().String(),
+		InputCount:       len(msgTx.TxIn),
+		OutputCount:      len(msgTx.TxOut),
+		TotalOutputValue: total,
+	}
+}
+
 func DecodeRawTransaction(txnHex string, params *chaincfg.Params) (*types.Transaction, error) {
+	mtx, err := DeserializeMsgTx(txnHex)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeMsgTx(mtx, params), nil
+}
+
+// DeserializeMsgTx deserializes a hex-encoded transaction to wire.MsgTx.
+func DeserializeMsgTx(txnHex string) (*wire.MsgTx, error) {
 	hexStr := txnHex
 
 	// Left-pad with zero if length of transaction hex is not even.
@@ -90,7 +130,7 @@ func DecodeRawTransaction(txnHex string, params *chaincfg.Params) (*types.Transa
 		return nil, fmt.Errorf("%s: %w: %s", ErrMsgTxDeserialize, err, txnHex)
 	}
 
-	return DecodeMsgTx(&mtx, params), nil
+	return &mtx, nil
 }
 
 // createVinList returns a slice of JSON objects for the inputs of the passed
@@ -168,6 +208,62 @@ func createVoutList(mtx *wire.MsgTx, chainParams *chaincfg.Params) []types.Outpu
 	return voutList
 }
 
+// AnnotateScriptSigAsm populates the disassembled signature script of the
+// non-coinbase inputs of the transaction, from the hex-encoded one.
+//
+// Since both the transaction index and the wallet paths decode the raw
+// transaction, this works regardless of how the transaction was fetched.
+func AnnotateScriptSigAsm(tx *types.Transaction) {
+	for idx, input := range tx.Inputs {
+		if input.ScriptSig == nil {
+			continue
+		}
+
+		script, err := hex.DecodeString(*input.ScriptSig)
+		if err != nil {
+			continue
+		}
+
+		// DisasmString returns the script disassembled up to the
+		// failure point, in case of error.
+		asm, _ := txscript.DisasmString(script)
+		tx.Inputs[idx].ScriptSigAsm = &asm
+	}
+}
+
+// maxNonReplaceableSequence is the lowest sequence number of an input that
+// does NOT signal opt-in replaceability, as defined by BIP-0125.
+const maxNonReplaceableSequence = wire.MaxTxInSequenceNum - 1
+
+// SignalsReplaceability reports whether the transaction explicitly signals
+// opt-in replace-by-fee, i.e., at least one of its inputs has a sequence
+// number lower than 0xfffffffe.
+//
+// Ref: https://github.com/bitcoin/bips/blob/master/bip-0125.mediawiki
+func SignalsReplaceability(mtx *wire.MsgTx) bool {
+	for _, txIn := range mtx.TxIn {
+		if txIn.Sequence < maxNonReplaceableSequence {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsWitnessScript reports whether the hex-encoded output script is a native
+// segwit program, of any witness version.
+//
+// Nested segwit outputs (P2SH-P2WPKH, P2SH-P2WSH) cannot be identified from
+// the output script alone, and are reported as false.
+func IsWitnessScript(scriptHex string) bool {
+	script, err := hex.DecodeString(scriptHex)
+	if err != nil {
+		return false
+	}
+
+	return txscript.IsWitnessProgram(script)
+}
+
 // witnessToHex formats the passed witness stack as a slice of hex-encoded
 // strings to be used in a JSON response.
 func witnessToHex(witness wire.TxWitness) []string {
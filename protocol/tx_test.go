@@ -0,0 +1,136 @@
+package protocol
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestDecodeMsgTxCoinbase(t *testing.T) {
+	// A segwit coinbase transaction commits to the witness of the block,
+	// with a 32 bytes witness reserved value as the only witness item.
+	segwitCoinbase := wire.NewMsgTx(wire.TxVersion)
+	segwitCoinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		SignatureScript:  []byte{0x03, 0x40, 0x5f, 0x0a},
+		Witness:          wire.TxWitness{bytes.Repeat([]byte{0x00}, 32)},
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	segwitCoinbase.AddTxOut(wire.NewTxOut(625000000, []byte{0x00, 0x14}))
+
+	tests := []struct {
+		name       string
+		msgTx      *wire.MsgTx
+		wantSize   int64
+		wantVSize  int64
+		wantWeight int64
+	}{
+		{
+			name:       "genesis coinbase",
+			msgTx:      chaincfg.MainNetParams.GenesisBlock.Transactions[0],
+			wantSize:   204,
+			wantVSize:  204,
+			wantWeight: 816,
+		},
+		{
+			name:       "segwit coinbase with witness reserved value",
+			msgTx:      segwitCoinbase,
+			wantSize:   102,
+			wantVSize:  75,
+			wantWeight: 300,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := DecodeMsgTx(tt.msgTx, &chaincfg.MainNetParams)
+
+			if !tx.IsCoinbase() {
+				t.Fatalf("DecodeMsgTx() is not a coinbase transaction")
+			}
+
+			if tx.Size != tt.wantSize || tx.VSize != tt.wantVSize || tx.Weight != tt.wantWeight {
+				t.Errorf("DecodeMsgTx() size/vsize/weight = %d/%d/%d, want %d/%d/%d",
+					tx.Size, tx.VSize, tx.Weight, tt.wantSize, tt.wantVSize, tt.wantWeight)
+			}
+		})
+	}
+}
+
+func TestSummarizeMsgTx(t *testing.T) {
+	prevHash, _ := chainhash.NewHashFromStr("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b")
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	for index := uint32(0); index < 3; index++ {
+		msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(prevHash, index), nil, nil))
+	}
+	msgTx.AddTxOut(wire.NewTxOut(70000, []byte{0x00, 0x14}))
+	msgTx.AddTxOut(wire.NewTxOut(25000, []byte{0x00, 0x14}))
+
+	summary := SummarizeMsgTx(msgTx)
+	tx := DecodeMsgTx(msgTx, &chaincfg.MainNetParams)
+
+	if summary.Hash != tx.Hash {
+		t.Errorf("SummarizeMsgTx() hash = %s, want %s", summary.Hash, tx.Hash)
+	}
+
+	if summary.InputCount != 3 || summary.InputCount != len(tx.Inputs) {
+		t.Errorf("SummarizeMsgTx() input count = %d, want %d", summary.InputCount, len(tx.Inputs))
+	}
+
+	if summary.OutputCount != 2 || summary.OutputCount != len(tx.Outputs) {
+		t.Errorf("SummarizeMsgTx() output count = %d, want %d", summary.OutputCount, len(tx.Outputs))
+	}
+
+	var total btcutil.Amount
+	for _, output := range tx.Outputs {
+		total += *output.Value
+	}
+
+	if summary.TotalOutputValue != 95000 || summary.TotalOutputValue != total {
+		t.Errorf("SummarizeMsgTx() total output value = %d, want %d", summary.TotalOutputValue, total)
+	}
+}
+
+func TestAnnotateScriptSigAsm(t *testing.T) {
+	// A P2PKH signature script pushes a DER signature, with its sighash
+	// type, followed by a compressed public key.
+	signature := "3044" +
+		"0220" + strings.Repeat("11", 32) +
+		"0220" + strings.Repeat("22", 32) +
+		"01"
+	pubKey := "02" + strings.Repeat("33", 32)
+
+	p2pkhScriptSig := "47" + signature + "21" + pubKey
+	invalidScriptSig := "zz"
+
+	tx := &types.Transaction{
+		Inputs: []types.Input{
+			{ScriptSig: &p2pkhScriptSig},
+			{ScriptSig: &invalidScriptSig},
+			{Coinbase: "03405f0a"},
+		},
+	}
+
+	AnnotateScriptSigAsm(tx)
+
+	want := signature + " " + pubKey
+	if asm := tx.Inputs[0].ScriptSigAsm; asm == nil || *asm != want {
+		t.Errorf("AnnotateScriptSigAsm() P2PKH asm = %v, want %s", asm, want)
+	}
+
+	if asm := tx.Inputs[1].ScriptSigAsm; asm != nil {
+		t.Errorf("AnnotateScriptSigAsm() invalid hex asm = %s, want nil", *asm)
+	}
+
+	if asm := tx.Inputs[2].ScriptSigAsm; asm != nil {
+		t.Errorf("AnnotateScriptSigAsm() coinbase asm = %s, want nil", *asm)
+	}
+}
@@ -0,0 +1,180 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Spend types of transaction inputs, as reported by InputWeights.
+const (
+	SpendCoinbase          = "coinbase"
+	SpendP2PKH             = "p2pkh"
+	SpendP2SH              = "p2sh"
+	SpendP2SHMultisig      = "p2sh_multisig"
+	SpendP2WPKH            = "p2wpkh"
+	SpendP2SHP2WPKH        = "p2sh_p2wpkh"
+	SpendP2WSH             = "p2wsh"
+	SpendP2WSHMultisig     = "p2wsh_multisig"
+	SpendP2SHP2WSH         = "p2sh_p2wsh"
+	SpendP2SHP2WSHMultisig = "p2sh_p2wsh_multisig"
+	SpendTaprootKeyPath    = "taproot_keypath"
+	SpendTaprootScriptPath = "taproot_scriptpath"
+	SpendUnknown           = "unknown"
+)
+
+const (
+	// outpointSize is the serialized size of the previous outpoint of an
+	// input: 32 bytes hash + 4 bytes index.
+	outpointSize = 36
+
+	// sequenceSize is the serialized size of the sequence of an input.
+	sequenceSize = 4
+
+	// compressedPubKeyLen is the length of a compressed public key.
+	compressedPubKeyLen = 33
+
+	// taprootControlBaseLen is the length of a taproot control block
+	// without any merkle path element.
+	taprootControlBaseLen = 33
+
+	// taprootLeafMask is applied on the first byte of a taproot control
+	// block to extract the leaf version.
+	taprootLeafMask = 0xfe
+
+	// taprootLeafTapscript is the leaf version of BIP-0342 tapscript.
+	taprootLeafTapscript = 0xc0
+)
+
+// InputWeights computes the weight contribution of each input of the
+// transaction, and identifies its spend type from the signature script and
+// witness data.
+//
+// The weight of an input is 4 times its non-witness serialized size, plus the
+// size of its witness data. The segwit marker and flag bytes (2 weight units)
+// are shared by all the inputs, and are not attributed to any of them.
+func InputWeights(mtx *wire.MsgTx) []types.InputWeight {
+	result := make([]types.InputWeight, len(mtx.TxIn))
+	isCoinbase := blockchain.IsCoinBaseTx(mtx)
+
+	for i, txIn := range mtx.TxIn {
+		baseSize := outpointSize + sequenceSize +
+			wire.VarIntSerializeSize(uint64(len(txIn.SignatureScript))) +
+			len(txIn.SignatureScript)
+
+		var witnessSize int
+		if mtx.HasWitness() {
+			witnessSize = txIn.Witness.SerializeSize()
+		}
+
+		weight := types.InputWeight{
+			InputIndex: i,
+			Weight:     int64(baseSize*blockchain.WitnessScaleFactor + witnessSize),
+		}
+
+		if isCoinbase {
+			weight.SpendType = SpendCoinbase
+		} else {
+			weight.SpendType, weight.Multisig = spendType(txIn)
+		}
+
+		result[i] = weight
+	}
+
+	return result
+}
+
+// spendType guesses the type of output spent by an input, and for multisig
+// spends, its m-of-n description.
+//
+// The spent output script is not needed, since the type can be inferred from
+// the structure of the signature script and the witness.
+func spendType(txIn *wire.TxIn) (string, string) {
+	pushes, err := txscript.PushedData(txIn.SignatureScript)
+	if err != nil {
+		return SpendUnknown, ""
+	}
+
+	witness := stripAnnex(txIn.Witness)
+
+	// Native segwit and taproot inputs have an empty signature script.
+	if len(txIn.SignatureScript) == 0 {
+		switch {
+		case len(witness) == 1 && isSchnorrSignature(witness[0]):
+			return SpendTaprootKeyPath, ""
+
+		case len(witness) >= 2 && isTaprootControlBlock(witness[len(witness)-1]):
+			return SpendTaprootScriptPath, ""
+
+		case len(witness) == 2 && len(witness[1]) == compressedPubKeyLen:
+			return SpendP2WPKH, ""
+
+		case len(witness) >= 1:
+			if multisig := multisigStats(witness[len(witness)-1]); multisig != "" {
+				return SpendP2WSHMultisig, multisig
+			}
+			return SpendP2WSH, ""
+		}
+
+		return SpendUnknown, ""
+	}
+
+	if len(pushes) == 0 {
+		return SpendUnknown, ""
+	}
+
+	redeemScript := pushes[len(pushes)-1]
+
+	// Nested segwit inputs have a single push of the witness program.
+	if len(pushes) == 1 && len(witness) > 0 {
+		switch {
+		case len(redeemScript) == 22: // OP_0 <20 bytes>
+			return SpendP2SHP2WPKH, ""
+		case len(redeemScript) == 34: // OP_0 <32 bytes>
+			if multisig := multisigStats(witness[len(witness)-1]); multisig != "" {
+				return SpendP2SHP2WSHMultisig, multisig
+			}
+			return SpendP2SHP2WSH, ""
+		}
+	}
+
+	if len(pushes) == 2 && isStrictDERSignature(pushes[0]) &&
+		(len(redeemScript) == compressedPubKeyLen || len(redeemScript) == 65) {
+		return SpendP2PKH, ""
+	}
+
+	if multisig := multisigStats(redeemScript); multisig != "" {
+		return SpendP2SHMultisig, multisig
+	}
+
+	return SpendP2SH, ""
+}
+
+// multisigStats returns the m-of-n description of a multisig script, or an
+// empty string if the script is not a standard multisig script.
+func multisigStats(script []byte) string {
+	if txscript.GetScriptClass(script) != txscript.MultiSigTy {
+		return ""
+	}
+
+	numPubKeys, numSigs, err := txscript.CalcMultiSigStats(script)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d-of-%d", numSigs, numPubKeys)
+}
+
+// isTaprootControlBlock checks if a witness element has the structure of a
+// BIP-0341 control block for a tapscript leaf.
+func isTaprootControlBlock(data []byte) bool {
+	if len(data) < taprootControlBaseLen || (len(data)-taprootControlBaseLen)%32 != 0 {
+		return false
+	}
+
+	return data[0]&taprootLeafMask == taprootLeafTapscript
+}
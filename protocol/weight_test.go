@@ -0,0 +1,129 @@
+package protocol
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ledgerhq/satstack/types"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// compressedPubKey returns a dummy compressed public key.
+func compressedPubKey(b byte) []byte {
+	return append([]byte{0x02}, bytes.Repeat([]byte{b}, 32)...)
+}
+
+// pushScript returns a signature script pushing the given data.
+func pushScript(t *testing.T, data ...[]byte) []byte {
+	builder := txscript.NewScriptBuilder()
+	for _, d := range data {
+		builder.AddData(d)
+	}
+
+	script, err := builder.Script()
+	if err != nil {
+		t.Fatalf("Script() error = %v", err)
+	}
+
+	return script
+}
+
+func TestInputWeights(t *testing.T) {
+	multisigScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_2).
+		AddData(compressedPubKey(0x01)).
+		AddData(compressedPubKey(0x02)).
+		AddData(compressedPubKey(0x03)).
+		AddOp(txscript.OP_3).
+		AddOp(txscript.OP_CHECKMULTISIG).
+		Script()
+	if err != nil {
+		t.Fatalf("Script() error = %v", err)
+	}
+
+	p2wpkhProgram := append([]byte{txscript.OP_0, 20}, bytes.Repeat([]byte{0x07}, 20)...)
+	p2wpkhWitness := wire.TxWitness{derSignature(txscript.SigHashAll), compressedPubKey(0x01)}
+
+	tests := []struct {
+		name            string
+		coinbase        bool
+		signatureScript []byte
+		witness         wire.TxWitness
+		want            types.InputWeight
+	}{
+		{
+			name:            "coinbase",
+			coinbase:        true,
+			signatureScript: []byte{0x03, 0x01, 0x02, 0x03},
+			want:            types.InputWeight{SpendType: SpendCoinbase, Weight: 180},
+		},
+		{
+			name:            "P2PKH",
+			signatureScript: pushScript(t, derSignature(txscript.SigHashAll), compressedPubKey(0x01)),
+			want:            types.InputWeight{SpendType: SpendP2PKH, Weight: 588},
+		},
+		{
+			name:    "P2WPKH",
+			witness: p2wpkhWitness,
+			want:    types.InputWeight{SpendType: SpendP2WPKH, Weight: 271},
+		},
+		{
+			name:            "P2SH-P2WPKH",
+			signatureScript: pushScript(t, p2wpkhProgram),
+			witness:         p2wpkhWitness,
+			want:            types.InputWeight{SpendType: SpendP2SHP2WPKH, Weight: 363},
+		},
+		{
+			name: "P2WSH multisig",
+			witness: wire.TxWitness{
+				{},
+				derSignature(txscript.SigHashAll),
+				derSignature(txscript.SigHashAll),
+				multisigScript,
+			},
+			want: types.InputWeight{SpendType: SpendP2WSHMultisig, Multisig: "2-of-3", Weight: 416},
+		},
+		{
+			name:    "taproot key path",
+			witness: wire.TxWitness{schnorrSignature(sigHashDefault)},
+			want:    types.InputWeight{SpendType: SpendTaprootKeyPath, Weight: 230},
+		},
+		{
+			name: "taproot script path",
+			witness: wire.TxWitness{
+				schnorrSignature(sigHashDefault),
+				[]byte{txscript.OP_TRUE},
+				controlBlock(),
+			},
+			want: types.InputWeight{SpendType: SpendTaprootScriptPath, Weight: 298},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outpoint := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 0}
+			if tt.coinbase {
+				outpoint = wire.OutPoint{Hash: chainhash.Hash{}, Index: wire.MaxPrevOutIndex}
+			}
+
+			mtx := wire.NewMsgTx(wire.TxVersion)
+			mtx.AddTxIn(&wire.TxIn{
+				PreviousOutPoint: outpoint,
+				SignatureScript:  tt.signatureScript,
+				Witness:          tt.witness,
+			})
+
+			got := InputWeights(mtx)
+			if len(got) != 1 {
+				t.Fatalf("InputWeights() returned %d inputs, want 1", len(got))
+			}
+
+			if got[0] != tt.want {
+				t.Errorf("InputWeights() = %+v, want %+v", got[0], tt.want)
+			}
+		})
+	}
+}
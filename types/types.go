@@ -5,18 +5,68 @@ import (
 )
 
 type OutputIdentifier struct {
-	Hash  string
-	Index uint32
+	Hash  string `json:"hash"`
+	Index uint32 `json:"index"`
 }
 type UTXOData struct {
-	Value   btcutil.Amount
-	Address string
+	Value    btcutil.Amount
+	Address  string
+	IsSegwit bool // whether the output script is a witness program
 }
 
 // UTXO models the data corresponding to unspent transaction outputs.
 // Convenience type; for limited use only.
 type UTXOs map[OutputIdentifier]UTXOData
 
+// UTXO models an unspent output of the wallet.
+type UTXO struct {
+	OutputHash    string         `json:"output_hash"`
+	OutputIndex   uint32         `json:"output_index"`
+	Value         btcutil.Amount `json:"value"`
+	Address       string         `json:"address,omitempty"`
+	ScriptHex     string         `json:"script_hex"`
+	ScriptType    string         `json:"script_type"`
+	Confirmations int64          `json:"confirmations"`
+	SafeToSpend   *bool          `json:"safe_to_spend,omitempty"` // only set when listing the UTXOs of the wallet
+}
+
+// WalletOutput models an output of a transaction that belongs to the wallet,
+// along with the HD derivation path of its address.
+type WalletOutput struct {
+	TxHash         string         `json:"tx_hash"`
+	OutputIndex    uint32         `json:"output_index"`
+	Value          btcutil.Amount `json:"value"`
+	Address        string         `json:"address"`
+	DerivationPath string         `json:"derivation_path"`
+}
+
+// UTXOList models a bounded list of UTXOs. If Truncated is true, only the
+// first UTXOs in (output_hash, output_index) order are returned, and Total is
+// the number of UTXOs of the wallet.
+type UTXOList struct {
+	Truncated bool   `json:"truncated"`
+	Total     int    `json:"total"`
+	UTXOs     []UTXO `json:"utxos"`
+}
+
+// ConsolidationEstimate models the fees involved in consolidating a set of
+// UTXOs now, versus spending them individually later. Fees are in satoshis.
+type ConsolidationEstimate struct {
+	ConsolidationVSize    int64           `json:"consolidation_vsize"`
+	ConsolidationFee      btcutil.Amount  `json:"consolidation_fee"`       // at the current fee rate
+	FutureFeeIndividual   btcutil.Amount  `json:"future_fee_individual"`   // spending the UTXOs later
+	FutureFeeConsolidated btcutil.Amount  `json:"future_fee_consolidated"` // spending the consolidated UTXO later
+	Savings               btcutil.Amount  `json:"savings"`                 // negative if not worth it
+	BreakEvenFeeRate      *btcutil.Amount `json:"break_even_fee_rate"`     // future fee rate, in sat/kvB
+}
+
+// ScriptTypeSummary models the aggregated value of the UTXOs of a given
+// script type.
+type ScriptTypeSummary struct {
+	Count int            `json:"count"`
+	Value btcutil.Amount `json:"value"`
+}
+
 // Input models data corresponding to transaction inputs.
 type Input struct {
 	Coinbase    string          `json:"coinbase,omitempty"`         // [coinbase] The coinbase encoded as hex
@@ -28,6 +78,26 @@ type Input struct {
 	Witness     []string        `json:"txinwitness,omitempty"`      // [non-coinbase] Array of hex-encoded witness data
 	InputIndex  *int            `json:"input_index,omitempty"`      // [all] Non-standard data required by Ledger Blockchain Explorer
 	Sequence    uint32          `json:"sequence"`                   // [all] Input sequence number, used to track unconfirmed txns
+
+	PrevoutIsSegwit bool `json:"prevout_is_segwit"` // [non-coinbase] Whether the spent output is segwit (native or nested)
+	Denied          bool `json:"denied,omitempty"`  // [non-coinbase] Whether Address is on the configured denylist
+
+	ScriptSigAsm *string `json:"script_signature_asm,omitempty"` // [non-coinbase] Disassembled signature script, in verbose mode only
+}
+
+// InputWeight models the weight contribution of a transaction input.
+type InputWeight struct {
+	InputIndex int    `json:"input_index"`
+	SpendType  string `json:"spend_type"`         // for ex: p2wpkh, taproot_keypath, p2wsh_multisig
+	Multisig   string `json:"multisig,omitempty"` // m-of-n, for multisig spends only
+	Weight     int64  `json:"weight"`             // in weight units
+}
+
+// InputSigHashes models the sighash types of the signatures of a transaction
+// input, for ex: ALL, SINGLE|ANYONECANPAY or DEFAULT (taproot only).
+type InputSigHashes struct {
+	InputIndex   int      `json:"input_index"`
+	SigHashTypes []string `json:"sighash_types"`
 }
 
 // Output models data corresponding to transaction outputs.
@@ -36,6 +106,10 @@ type Output struct {
 	Value       *btcutil.Amount `json:"value,omitempty"`        // Value of output in satoshis
 	ScriptHex   string          `json:"script_hex"`             // Hex-encoded script
 	Address     string          `json:"address,omitempty"`      // Address of the UTXO; can be empty
+	Denied      bool            `json:"denied,omitempty"`       // Whether Address is on the configured denylist
+
+	SpentByTxid       string `json:"spent_by_txid,omitempty"`        // Hash of the transaction spending the output, if known
+	SpentByInputIndex *int   `json:"spent_by_input_index,omitempty"` // Index of the spending input in SpentByTxid
 }
 
 // Block models data corresponding to a block, but with limited information.
@@ -46,6 +120,34 @@ type Block struct {
 	Height       int64     `json:"height"`        // integer
 	Time         string    `json:"time"`          // RFC3339 format
 	Transactions *[]string `json:"txs,omitempty"` // optional list of 0x prefixed transaction IDs
+
+	LocalTime string `json:"local_time,omitempty"` // RFC3339 format, in the configured timezone
+}
+
+// BlockIntervals models statistics on the time between consecutive blocks,
+// over the most recent blocks. Durations are in seconds.
+type BlockIntervals struct {
+	Blocks  int     `json:"blocks"` // number of intervals
+	Average float64 `json:"average"`
+	Median  float64 `json:"median"`
+	Min     int64   `json:"min"`
+	Max     int64   `json:"max"`
+}
+
+// BlockChainMembership indicates whether a block is part of the main chain,
+// i.e., it is an ancestor of the current tip.
+type BlockChainMembership struct {
+	Hash        string `json:"hash"`
+	Height      int64  `json:"height"`
+	InMainChain bool   `json:"in_main_chain"`
+}
+
+// CommonBlock models the most recent block shared by a client and the node,
+// along with the tip of the node, so that the client knows how far behind it
+// is. Common is nil if the client does not share any block with the node.
+type CommonBlock struct {
+	Common *Block `json:"common"`
+	Tip    *Block `json:"tip"`
 }
 
 // BlockWithTransactions is a struct that embeds Block, but also contains
@@ -55,6 +157,30 @@ type BlockWithTransactions struct {
 	Transactions []string `json:"txs"` // 0x prefixed
 }
 
+// BlockDelta models the changes to the UTXO set caused by a block. It can be
+// used by clients to maintain an external UTXO set, by replaying blocks.
+type BlockDelta struct {
+	Hash    string             `json:"hash"`
+	Height  int64              `json:"height"`
+	Spent   []OutputIdentifier `json:"spent"`   // outpoints spent by non-coinbase inputs
+	Created []OutputIdentifier `json:"created"` // outpoints of all outputs
+}
+
+// BlockFees models the transactions of a block, with the fee of each of
+// them.
+type BlockFees struct {
+	Hash         string           `json:"hash"`
+	Height       int64            `json:"height"`
+	Transactions []TransactionFee `json:"txs"`
+}
+
+// TransactionFee models the fee paid by a transaction. Fee is nil if it
+// could not be computed.
+type TransactionFee struct {
+	Hash string          `json:"hash"`
+	Fee  *btcutil.Amount `json:"fee"`
+}
+
 // Transaction represents the principal type to model the response of the GetTransaction handler.
 type Transaction struct {
 	ID            string          `json:"id"` // only in v3 explorer
@@ -62,14 +188,261 @@ type Transaction struct {
 	ReceivedAt    string          `json:"received_at"`
 	LockTime      uint32          `json:"lock_time"`
 	Fees          *btcutil.Amount `json:"fees"`
+	FeeRate       *FeeRate        `json:"fee_rate,omitempty"`
+	Size          int64           `json:"size"`             // serialized size in bytes, including witness data
+	VSize         int64           `json:"vsize"`            // virtual size in vbytes, i.e., weight / 4 rounded up
+	Weight        int64           `json:"weight"`           // weight units
 	Amount        *btcutil.Amount `json:"amount,omitempty"` // legacy field for v2 explorer
 	Confirmations uint64          `json:"confirmations"`
 	Inputs        []Input         `json:"inputs"`
 	Outputs       []Output        `json:"outputs"`
 	Block         *Block          `json:"block"`
+
+	// FeePercentOfOutputs is the fee as a percentage of the total value of
+	// the outputs. Missing if the fee or the output total is unknown or zero.
+	FeePercentOfOutputs *float64 `json:"fee_percent_of_outputs,omitempty"`
+
+	// AddressReuse is only populated if the analysis is requested.
+	AddressReuse *AddressReuse `json:"address_reuse,omitempty"`
+
+	// Number of distinct addresses spent from, and paid to.
+	DistinctInputAddresses  int `json:"distinct_input_addresses"`
+	DistinctOutputAddresses int `json:"distinct_output_addresses"`
+	// StatusLabel is a human-readable label of the confirmation depth, for
+	// ex: Unconfirmed, 1 confirmation, 6+ confirmations or Finalized.
+	StatusLabel string `json:"status_label,omitempty"`
+}
+
+// AddressReuse models the addresses reused by the outputs of a transaction.
+//
+// An output address is reused if it is paid by another output of the same
+// transaction, spent by one of its inputs, or paid by an earlier transaction
+// of the history.
+type AddressReuse struct {
+	Reused    bool     `json:"reused"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// IsCoinbase reports whether the transaction is a coinbase transaction, i.e.,
+// it has a single input with no previous output.
+func (tx *Transaction) IsCoinbase() bool {
+	return len(tx.Inputs) == 1 && len(tx.Inputs[0].Coinbase) > 0
+}
+
+// DistinctAddressCounts returns the number of distinct addresses the
+// transaction spends from, and pays to.
+//
+// Outputs without an address, such as OP_RETURN outputs, are not counted.
+// Input addresses are only known once the spent outputs are resolved.
+func (tx *Transaction) DistinctAddressCounts() (inputs int, outputs int) {
+	inputAddresses := make(map[string]struct{})
+	for _, input := range tx.Inputs {
+		if input.Address != "" {
+			inputAddresses[input.Address] = struct{}{}
+		}
+	}
+
+	outputAddresses := make(map[string]struct{})
+	for _, output := range tx.Outputs {
+		if output.Address != "" {
+			outputAddresses[output.Address] = struct{}{}
+		}
+	}
+
+	return len(inputAddresses), len(outputAddresses)
+}
+
+// CoinbaseFilter indicates how coinbase transactions must be treated while
+// listing the transactions of a block.
+type CoinbaseFilter string
+
+const (
+	// CoinbaseInclude keeps the coinbase transaction along with the rest of
+	// the block transactions. This is the default behaviour.
+	CoinbaseInclude CoinbaseFilter = "include"
+
+	// CoinbaseExclude drops the coinbase transaction from the listing.
+	CoinbaseExclude CoinbaseFilter = "exclude"
+
+	// CoinbaseOnly drops every transaction except the coinbase.
+	CoinbaseOnly CoinbaseFilter = "only"
+)
+
+// TransactionSummary is a lean representation of a transaction, for
+// bandwidth-sensitive listings.
+type TransactionSummary struct {
+	Hash             string         `json:"hash"`
+	InputCount       int            `json:"input_count"`
+	OutputCount      int            `json:"output_count"`
+	TotalOutputValue btcutil.Amount `json:"total_output_value"`
+}
+
+// Finality models whether a transaction can be included in the next block,
+// given its absolute and relative lock times.
+//
+// If not final, MinHeight is the height of the first block that may include
+// the transaction, and MinMedianTimePast (Unix) is the median time past that
+// the parent of this block must reach. They are omitted if already satisfied.
+// UnconfirmedPrevouts indicates that an output spent with a relative lock
+// time is unconfirmed, so the transaction cannot be final yet.
+type Finality struct {
+	Final               bool   `json:"final"`
+	MinHeight           *int64 `json:"min_height,omitempty"`
+	MinMedianTimePast   *int64 `json:"min_median_time_past,omitempty"`
+	UnconfirmedPrevouts bool   `json:"unconfirmed_prevouts,omitempty"`
+}
+
+// FeeRate models a fee rate in the units commonly used by clients. All the
+// representations are derived from the same value, and may be fractional.
+type FeeRate struct {
+	SatPerVByte float64 `json:"sat_per_vbyte"`
+	SatPerKvB   float64 `json:"sat_per_kvb"`
+	BTCPerKB    float64 `json:"btc_per_kb"`
+}
+
+// SizeLimits models the compliance of a transaction with the limits on its
+// size, enforced by relay policy (standardness) and consensus.
+type SizeLimits struct {
+	Weight          int64 `json:"weight"`
+	VSize           int64 `json:"vsize"`
+	WithinStandard  bool  `json:"within_standard"`
+	WithinConsensus bool  `json:"within_consensus"`
+}
+
+// CanonicalTransaction is a stable representation of the inputs and outputs
+// of a transaction, meant to be displayed on a hardware wallet for
+// verification. The same transaction always serializes identically.
+//
+// Inputs are sorted by outpoint, and outputs by value then script, as in
+// BIP-0069. All values are in satoshis.
+type CanonicalTransaction struct {
+	Hash    string            `json:"hash"`
+	Inputs  []CanonicalInput  `json:"inputs"`
+	Outputs []CanonicalOutput `json:"outputs"`
+	Fee     btcutil.Amount    `json:"fee"`
+}
+
+// CanonicalInput models an input of a CanonicalTransaction, with its
+// resolved previous output.
+type CanonicalInput struct {
+	OutputHash  string         `json:"output_hash"`
+	OutputIndex uint32         `json:"output_index"`
+	Address     string         `json:"address"`
+	Value       btcutil.Amount `json:"value"`
+}
+
+// CanonicalOutput models an output of a CanonicalTransaction.
+type CanonicalOutput struct {
+	Address   string         `json:"address"`
+	ScriptHex string         `json:"script_hex"`
+	Value     btcutil.Amount `json:"value"`
+}
+
+// TransactionFlow models the flow of value through a transaction, for
+// visualization as a Sankey diagram: from the previous outputs spent by the
+// inputs, to the outputs. The fee is the implicit sink, so that the sum of
+// the input values equals the sum of the output values plus the fee.
+//
+// Coinbase transactions are the exception: their fee is the total fees of
+// the block, which are already part of the single coinbase input flow.
+type TransactionFlow struct {
+	Hash    string         `json:"hash"`
+	Inputs  []Flow         `json:"inputs"`
+	Outputs []Flow         `json:"outputs"`
+	Fee     btcutil.Amount `json:"fee"`
+}
+
+// Flow models the value flowing from an input, or to an output, of a
+// TransactionFlow. Index is the position of the input or output in the
+// transaction. Coinbase is set for the newly created coins of a coinbase
+// transaction, which have no source address.
+type Flow struct {
+	Index    int            `json:"index"`
+	Address  string         `json:"address,omitempty"`
+	Value    btcutil.Amount `json:"value"`
+	Coinbase bool           `json:"coinbase,omitempty"`
 }
 
 type Addresses struct {
 	Truncated    bool          `json:"truncated"`
 	Transactions []Transaction `json:"txs"`
 }
+
+// SyncEvent models an event of the incremental synchronization feed of an
+// account.
+//
+// Each event is either a newly confirmed transaction, a transaction removed
+// from the main chain by a reorg (Removed is true), or the final event
+// carrying the LastBlock to resume from.
+type SyncEvent struct {
+	TxHash    string `json:"tx_hash,omitempty"`
+	Block     *Block `json:"block,omitempty"`
+	Removed   bool   `json:"removed,omitempty"`
+	LastBlock string `json:"last_block,omitempty"`
+}
+
+// Balances models the balance of the wallet, split by confirmation status.
+// All values are in satoshis.
+type Balances struct {
+	Confirmed btcutil.Amount `json:"confirmed"` // trusted outputs, spendable
+	Pending   btcutil.Amount `json:"pending"`   // untrusted unconfirmed outputs, in the mempool
+	Immature  btcutil.Amount `json:"immature"`  // coinbase outputs with less than 100 confirmations
+}
+
+// Replaceability models the BIP-0125 replaceability of an unconfirmed
+// transaction.
+type Replaceability struct {
+	Replaceable bool `json:"replaceable"` // Signaling or Inherited
+	Signaling   bool `json:"signaling"`   // transaction itself signals RBF
+	Inherited   bool `json:"inherited"`   // an unconfirmed ancestor signals RBF
+}
+
+// MempoolRank models the position of an unconfirmed transaction in the
+// mempool, sorted by decreasing fee rate. Rank 1 is the transaction with the
+// highest fee rate.
+type MempoolRank struct {
+	Rank  int `json:"rank"`
+	Total int `json:"total"`
+}
+
+// MempoolDescendants models the unconfirmed descendants of a mempool
+// transaction, with their aggregate virtual size and fee.
+type MempoolDescendants struct {
+	Descendants []MempoolDescendant `json:"descendants"`
+	VSize       int64               `json:"vsize"`
+	Fee         btcutil.Amount      `json:"fee"`
+}
+
+// MempoolDescendant models a descendant of a mempool transaction.
+type MempoolDescendant struct {
+	Hash  string         `json:"hash"`
+	VSize int64          `json:"vsize"`
+	Fee   btcutil.Amount `json:"fee"`
+}
+
+// ElectrumHistoryItem models an entry of the history of an address, in the
+// format of the blockchain.scripthash.get_history method of the Electrum
+// protocol.
+//
+// Height is 0 for mempool transactions with all inputs confirmed, and -1 for
+// mempool transactions spending unconfirmed outputs. Fee is only set for
+// mempool transactions.
+type ElectrumHistoryItem struct {
+	TxHash string          `json:"tx_hash"`
+	Height int64           `json:"height"`
+	Fee    *btcutil.Amount `json:"fee,omitempty"`
+}
+
+// AccountSync models the data required by Ledger Live to synchronize an
+// account in a single call.
+//
+// LastBlock is the cursor to pass as the starting block of the next
+// (incremental) synchronization.
+type AccountSync struct {
+	BlockHeight  int64                     `json:"block_height"`
+	BlockHash    string                    `json:"block_hash"`
+	UTXOs        []UTXO                    `json:"utxos"`
+	Transactions []Transaction             `json:"txs"`
+	Fees         map[string]btcutil.Amount `json:"fees"`
+	LastBlock    string                    `json:"last_block"`
+}
@@ -0,0 +1,62 @@
+package types
+
+import "testing"
+
+func TestDistinctAddressCounts(t *testing.T) {
+	const (
+		addrA = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+		addrB = "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"
+		addrC = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+		addrD = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+		addrE = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	)
+
+	tests := []struct {
+		name        string
+		inputs      []string
+		outputs     []string // empty for outputs without an address
+		wantInputs  int
+		wantOutputs int
+	}{
+		{
+			name:        "OP_RETURN excluded",
+			inputs:      []string{addrA, addrB, addrA},
+			outputs:     []string{addrC, addrD, "", addrE},
+			wantInputs:  2,
+			wantOutputs: 3,
+		},
+		{
+			name:        "repeated output address",
+			inputs:      []string{addrA},
+			outputs:     []string{addrC, addrC},
+			wantInputs:  1,
+			wantOutputs: 1,
+		},
+		{
+			name:        "unresolved inputs",
+			inputs:      []string{"", ""},
+			outputs:     []string{addrC},
+			wantInputs:  0,
+			wantOutputs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := Transaction{}
+			for _, address := range tt.inputs {
+				tx.Inputs = append(tx.Inputs, Input{Address: address})
+			}
+
+			for _, address := range tt.outputs {
+				tx.Outputs = append(tx.Outputs, Output{Address: address})
+			}
+
+			inputs, outputs := tx.DistinctAddressCounts()
+			if inputs != tt.wantInputs || outputs != tt.wantOutputs {
+				t.Errorf("DistinctAddressCounts() = %d/%d, want %d/%d",
+					inputs, outputs, tt.wantInputs, tt.wantOutputs)
+			}
+		})
+	}
+}
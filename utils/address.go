@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+// AddressEncodings returns the canonical form of an address, along with the
+// alternate encodings of the same public key hash supported by the network.
+//
+// The canonical form is the address re-encoded by btcutil, which for example
+// lowercases bech32 addresses.
+//
+// Only P2PKH and P2WPKH addresses have alternate encodings, since both commit
+// to the HASH160 of a public key. Caution: the alternate encoding is only
+// spendable by the same key if the public key is compressed, which is always
+// the case for keys derived by a Ledger device.
+//
+// There are no valid conversions for other script types:
+//   - P2SH commits to the hash of an arbitrary script.
+//   - P2WSH commits to a 32-byte SHA256 hash, which has no legacy equivalent.
+func AddressEncodings(address string, params *chaincfg.Params) (string, []string, error) {
+	decoded, err := btcutil.DecodeAddress(address, params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var alternate btcutil.Address
+
+	switch addr := decoded.(type) {
+	case *btcutil.AddressPubKeyHash:
+		alternate, err = btcutil.NewAddressWitnessPubKeyHash(addr.Hash160()[:], params)
+	case *btcutil.AddressWitnessPubKeyHash:
+		alternate, err = btcutil.NewAddressPubKeyHash(addr.Hash160()[:], params)
+	}
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	alternates := []string{}
+	if alternate != nil {
+		alternates = append(alternates, alternate.EncodeAddress())
+	}
+
+	return decoded.EncodeAddress(), alternates, nil
+}
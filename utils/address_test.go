@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestAddressEncodings(t *testing.T) {
+	// P2PKH and P2WPKH encodings of the same HASH160, from BIP-0173.
+	const (
+		p2pkh  = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+		p2wpkh = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+		p2sh   = "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"
+		p2wsh  = "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3"
+	)
+
+	tests := []struct {
+		name           string
+		address        string
+		wantCanonical  string
+		wantAlternates []string
+		wantErr        bool
+	}{
+		{"P2PKH", p2pkh, p2pkh, []string{p2wpkh}, false},
+		{"P2WPKH", p2wpkh, p2wpkh, []string{p2pkh}, false},
+		{"P2SH", p2sh, p2sh, []string{}, false},
+		{"P2WSH", p2wsh, p2wsh, []string{}, false},
+		{"uppercase bech32", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", p2wpkh, []string{p2pkh}, false},
+		{"invalid address", "notanaddress", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, alternates, err := AddressEncodings(tt.address, &chaincfg.MainNetParams)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddressEncodings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if canonical != tt.wantCanonical {
+				t.Errorf("AddressEncodings() canonical = %s, want %s", canonical, tt.wantCanonical)
+			}
+
+			if !reflect.DeepEqual(alternates, tt.wantAlternates) {
+				t.Errorf("AddressEncodings() alternates = %v, want %v", alternates, tt.wantAlternates)
+			}
+		})
+	}
+}
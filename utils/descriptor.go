@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+// descriptorScript is the type of output script of a descriptor.
+type descriptorScript int
+
+const (
+	scriptP2PKH      descriptorScript = iota // pkh(KEY)      - BIP44
+	scriptP2SHP2WPKH                         // sh(wpkh(KEY)) - BIP49
+	scriptP2WPKH                             // wpkh(KEY)     - BIP84
+	scriptP2TR                               // tr(KEY)       - BIP86
+)
+
+// DeriveAddresses derives count addresses, starting at index start, from a
+// ranged output descriptor. The derivation is performed locally, without
+// involving the Bitcoin node, and does NOT import anything in its wallet.
+//
+// Supported descriptors are pkh(), sh(wpkh()), wpkh() and tr(), with an
+// extended public key followed by unhardened derivation steps, and ending
+// with /*. The optional key origin and checksum are ignored. For example:
+//
+//	wpkh([b91fb6c1/84'/0'/3']xpub6D1gvTP...VeMLtH6/0/*)#checksum
+//
+// Taproot descriptors are supported for BIP86 key path spending only, i.e.,
+// without a script tree.
+func DeriveAddresses(descriptor string, start int, count int, params *chaincfg.Params) ([]string, error) {
+	if start < 0 || count < 0 {
+		return nil, fmt.Errorf("%s: invalid range [%d, %d)",
+			ErrMalformedDescriptor, start, start+count)
+	}
+
+	script, keyExpr, err := parseDescriptorScript(descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parseDescriptorKey(keyExpr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, 0, count)
+	for i := start; i < start+count; i++ {
+		child, err := key.Child(uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("%s (#%d): %w", ErrMalformedDescriptor, i, err)
+		}
+
+		address, err := scriptAddress(child, script, params)
+		if err != nil {
+			return nil, fmt.Errorf("%s (#%d): %w", ErrMalformedDescriptor, i, err)
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// parseDescriptorScript returns the script type of a descriptor, and its
+// key expression.
+func parseDescriptorScript(descriptor string) (descriptorScript, string, error) {
+	desc := strings.TrimSpace(strings.Split(descriptor, "#")[0]) // strip out the checksum
+
+	wrappers := []struct {
+		prefix string
+		suffix string
+		script descriptorScript
+	}{
+		{"sh(wpkh(", "))", scriptP2SHP2WPKH},
+		{"wpkh(", ")", scriptP2WPKH},
+		{"pkh(", ")", scriptP2PKH},
+		{"tr(", ")", scriptP2TR},
+	}
+
+	for _, w := range wrappers {
+		if strings.HasPrefix(desc, w.prefix) && strings.HasSuffix(desc, w.suffix) {
+			return w.script, desc[len(w.prefix) : len(desc)-len(w.suffix)], nil
+		}
+	}
+
+	return 0, "", fmt.Errorf("%s: %s", ErrUnsupportedDescriptor, descriptor)
+}
+
+// parseDescriptorKey parses a ranged key expression of a descriptor, and
+// returns the extended key right before the wildcard step.
+func parseDescriptorKey(keyExpr string, params *chaincfg.Params) (*hdkeychain.ExtendedKey, error) {
+	// Strip out the key origin, for ex: [b91fb6c1/84'/0'/3']
+	if strings.HasPrefix(keyExpr, "[") {
+		end := strings.Index(keyExpr, "]")
+		if end == -1 {
+			return nil, fmt.Errorf("%s: unterminated key origin", ErrMalformedDescriptor)
+		}
+		keyExpr = keyExpr[end+1:]
+	}
+
+	steps := strings.Split(keyExpr, "/")
+	if len(steps) < 2 || steps[len(steps)-1] != "*" {
+		return nil, fmt.Errorf("%s: not a ranged descriptor", ErrUnsupportedDescriptor)
+	}
+
+	key, err := hdkeychain.NewKeyFromString(steps[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrMalformedDescriptor, err)
+	}
+
+	if !key.IsForNet(params) {
+		return nil, fmt.Errorf("%s: extended key not for network %s",
+			ErrMalformedDescriptor, params.Name)
+	}
+
+	for _, step := range steps[1 : len(steps)-1] {
+		// Hardened derivation is not possible from an extended public
+		// key, and is rejected by ParseUint.
+		index, err := strconv.ParseUint(step, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid derivation step '%s'",
+				ErrUnsupportedDescriptor, step)
+		}
+
+		key, err = key.Child(uint32(index))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrMalformedDescriptor, err)
+		}
+	}
+
+	return key, nil
+}
+
+// scriptAddress returns the encoded address of the given script type, paying
+// to the public key of the extended key.
+func scriptAddress(key *hdkeychain.ExtendedKey, script descriptorScript, params *chaincfg.Params) (string, error) {
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+	var address btcutil.Address
+
+	switch script {
+	case scriptP2PKH:
+		address, err = btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+	case scriptP2WPKH:
+		address, err = btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+	case scriptP2SHP2WPKH:
+		var witnessAddr btcutil.Address
+		witnessAddr, err = btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+		if err != nil {
+			return "", err
+		}
+
+		var redeemScript []byte
+		redeemScript, err = txscript.PayToAddrScript(witnessAddr)
+		if err != nil {
+			return "", err
+		}
+
+		address, err = btcutil.NewAddressScriptHash(redeemScript, params)
+	case scriptP2TR:
+		outputKey, err := taprootOutputKey(pubKey)
+		if err != nil {
+			return "", err
+		}
+
+		return encodeTaprootAddress(params.Bech32HRPSegwit, outputKey)
+	default:
+		return "", ErrUnsupportedDescriptor
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return address.EncodeAddress(), nil
+}
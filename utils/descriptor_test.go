@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+// testRootKey is the BIP32 root key of the "abandon abandon abandon abandon
+// abandon abandon abandon abandon abandon abandon abandon about" mnemonic,
+// used in the test vectors of BIP49, BIP84 and BIP86.
+const testRootKey = "xprv9s21ZrQH143K3GJpoapnV8SFfukcVBSfeCficPSGfubmSFDxo1kuHnLisriDvSnRRuL2Qrg5ggqHKNVpxR86QEC8w35uxmGoggxtQTPvfUu"
+
+// accountXPub derives the extended public key of the first account of the
+// given purpose, on mainnet, i.e., m/purpose'/0'/0'.
+func accountXPub(t *testing.T, purpose uint32) string {
+	key, err := hdkeychain.NewKeyFromString(testRootKey)
+	if err != nil {
+		t.Fatalf("NewKeyFromString() error = %v", err)
+	}
+
+	for _, index := range []uint32{purpose, 0, 0} {
+		key, err = key.Child(hdkeychain.HardenedKeyStart + index)
+		if err != nil {
+			t.Fatalf("Child() error = %v", err)
+		}
+	}
+
+	xpub, err := key.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter() error = %v", err)
+	}
+
+	return xpub.String()
+}
+
+func TestDeriveAddresses(t *testing.T) {
+	tests := []struct {
+		name       string
+		descriptor func(xpub string) string
+		purpose    uint32
+		start      int
+		want       []string
+	}{
+		{
+			name:       "BIP44",
+			descriptor: func(xpub string) string { return "pkh(" + xpub + "/0/*)" },
+			purpose:    44,
+			want: []string{
+				"1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+				"1Ak8PffB2meyfYnbXZR9EGfLfFZVpzJvQP",
+			},
+		},
+		{
+			name:       "BIP49",
+			descriptor: func(xpub string) string { return "sh(wpkh(" + xpub + "/0/*))" },
+			purpose:    49,
+			want: []string{
+				"37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf",
+			},
+		},
+		{
+			name:       "BIP84",
+			descriptor: func(xpub string) string { return "wpkh([73c5da0a/84'/0'/0']" + xpub + "/0/*)#checksum" },
+			purpose:    84,
+			want: []string{
+				"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+				"bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g",
+			},
+		},
+		{
+			name:       "BIP84 change",
+			descriptor: func(xpub string) string { return "wpkh(" + xpub + "/1/*)" },
+			purpose:    84,
+			want: []string{
+				"bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el",
+			},
+		},
+		{
+			name:       "BIP86",
+			descriptor: func(xpub string) string { return "tr([73c5da0a/86'/0'/0']" + xpub + "/0/*)" },
+			purpose:    86,
+			want: []string{
+				"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+				"bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+			},
+		},
+		{
+			name:       "BIP86 change",
+			descriptor: func(xpub string) string { return "tr(" + xpub + "/1/*)" },
+			purpose:    86,
+			want: []string{
+				"bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7",
+			},
+		},
+		{
+			name:       "BIP86 with offset",
+			descriptor: func(xpub string) string { return "tr(" + xpub + "/0/*)" },
+			purpose:    86,
+			start:      1,
+			want: []string{
+				"bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			descriptor := tt.descriptor(accountXPub(t, tt.purpose))
+
+			got, err := DeriveAddresses(descriptor, tt.start, len(tt.want), &chaincfg.MainNetParams)
+			if err != nil {
+				t.Fatalf("DeriveAddresses() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeriveAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeriveAddressesErrors(t *testing.T) {
+	xpub := accountXPub(t, 84)
+
+	tests := []struct {
+		name       string
+		descriptor string
+		start      int
+		want       error
+	}{
+		{"unsupported script", "combo(" + xpub + "/0/*)", 0, ErrUnsupportedDescriptor},
+		{"not ranged", "wpkh(" + xpub + "/0/0)", 0, ErrUnsupportedDescriptor},
+		{"hardened step", "wpkh(" + xpub + "/0'/*)", 0, ErrUnsupportedDescriptor},
+		{"unterminated origin", "wpkh([73c5da0a/84'" + xpub + "/0/*)", 0, ErrMalformedDescriptor},
+		{"invalid range", "wpkh(" + xpub + "/0/*)", -1, ErrMalformedDescriptor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DeriveAddresses(tt.descriptor, tt.start, 1, &chaincfg.MainNetParams)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want.Error()) {
+				t.Errorf("DeriveAddresses() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	_, err := DeriveAddresses("wpkh("+xpub+"/0/*)", 0, 1, &chaincfg.TestNet3Params)
+	if err == nil || !strings.HasPrefix(err.Error(), ErrMalformedDescriptor.Error()) {
+		t.Errorf("DeriveAddresses() on wrong network error = %v, want %v", err, ErrMalformedDescriptor)
+	}
+}
@@ -0,0 +1,13 @@
+package utils
+
+import "errors"
+
+var (
+	// ErrUnsupportedDescriptor indicates that an output descriptor is valid,
+	// but cannot be handled locally by SatStack.
+	ErrUnsupportedDescriptor = errors.New("unsupported descriptor")
+
+	// ErrMalformedDescriptor indicates that an output descriptor could not be
+	// parsed.
+	ErrMalformedDescriptor = errors.New("malformed descriptor")
+)
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+const (
+	// bech32mConst is the constant of the BIP-0350 bech32m checksum, used by
+	// native segwit addresses of witness version 1 and above.
+	bech32mConst = 0x2bc830a3
+
+	// bech32Charset is the alphabet of the bech32 and bech32m encodings.
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// taprootWitnessVersion is the witness version of taproot outputs.
+	taprootWitnessVersion = 1
+)
+
+// taprootOutputKey returns the x-only output key of a BIP-0086 taproot
+// output, i.e., the internal key tweaked with no script path:
+//
+//	Q = P + int(taggedHash("TapTweak", bytes(P)))·G
+//
+// where P is the internal public key with an even y coordinate.
+func taprootOutputKey(internalKey *btcec.PublicKey) ([]byte, error) {
+	curve := btcec.S256()
+
+	// Lift the x coordinate to the point with an even y coordinate.
+	px, py := internalKey.X, internalKey.Y
+	if py.Bit(0) == 1 {
+		py = new(big.Int).Sub(curve.P, py)
+	}
+
+	xOnly := padTo32(px.Bytes())
+
+	tweak := taggedHash("TapTweak", xOnly)
+	if new(big.Int).SetBytes(tweak).Cmp(curve.N) >= 0 {
+		return nil, errors.New("taproot tweak exceeds curve order")
+	}
+
+	tx, ty := curve.ScalarBaseMult(tweak)
+	qx, qy := curve.Add(px, py, tx, ty)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, errors.New("taproot output key is the point at infinity")
+	}
+
+	return padTo32(qx.Bytes()), nil
+}
+
+// taggedHash computes the BIP-0340 tagged hash of the message:
+//
+//	SHA256(SHA256(tag) || SHA256(tag) || msg)
+func taggedHash(tag string, msg []byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	h.Write(msg)
+
+	return h.Sum(nil)
+}
+
+// padTo32 left-pads a big-endian integer with zeros, up to 32 bytes.
+func padTo32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+
+	padded := make([]byte, 32)
+	copy(padded[32-len(b):], b)
+	return padded
+}
+
+// encodeTaprootAddress encodes a taproot witness program as a bech32m
+// address, with the given human-readable part.
+//
+// It is needed since btcutil only implements the bech32 encoding of witness
+// version 0 addresses.
+func encodeTaprootAddress(hrp string, program []byte) (string, error) {
+	converted, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	data := append([]byte{taprootWitnessVersion}, converted...)
+
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, make([]byte, 6)...)
+	polymod := bech32Polymod(values) ^ bech32mConst
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+
+	for _, b := range data {
+		sb.WriteByte(bech32Charset[b])
+	}
+
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+
+	return sb.String(), nil
+}
+
+// bech32Polymod computes the BIP-0173 checksum polynomial of 5-bit values.
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+// bech32HRPExpand expands the human-readable part for checksum computation.
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+
+	expanded = append(expanded, 0)
+
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+
+	return expanded
+}
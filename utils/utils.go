@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -31,16 +32,38 @@ func ParseRFC3339Timestamp(timestamp string) (*int64, error) {
 	return &tUnix, nil
 }
 
-// ParseSatoshi converts a float64 bitcoin value to satoshis.
-// Named after ParseInt function.
-func ParseSatoshi(value float64) btcutil.Amount {
-	// Convert BTC value to satoshi without losing precision.
-	amount, err := btcutil.NewAmount(value)
+// FormatTimestampIn converts a timestamp in RFC3339 format to the same
+// format in the given location. The input is returned as-is if it cannot be
+// parsed.
+func FormatTimestampIn(timestamp string, location *time.Location) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
 	if err != nil {
+		return timestamp
+	}
+
+	return t.In(location).Format(time.RFC3339)
+}
+
+// BitcoinDecimals is the number of decimal places of a bitcoin, i.e., the
+// number of satoshis in a bitcoin is 10^BitcoinDecimals.
+const BitcoinDecimals = 8
+
+// ParseSmallestUnit converts a float64 value, expressed in the main unit of a
+// currency with the given number of decimal places, to the smallest unit of
+// that currency.
+//
+// All the values reported by the node must be converted with this function,
+// using the decimals of the connected chain (see bus.DecimalsFromChain).
+func ParseSmallestUnit(value float64, decimals int) btcutil.Amount {
+	// Same checks as btcutil.NewAmount, which assumes 8 decimal places.
+	if math.IsNaN(value) || math.IsInf(value, 0) {
 		// TODO: Log an error here
 		return -1
 	}
-	return amount
+
+	// Round to the nearest integer to avoid losing precision, since the
+	// value cannot be represented exactly as a float64.
+	return btcutil.Amount(math.Round(value * math.Pow10(decimals)))
 }
 
 func ParseChainHash(hash string) (*chainhash.Hash, error) {
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+)
+
+func TestParseSmallestUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		decimals int
+		want     btcutil.Amount
+	}{
+		{"one bitcoin", 1, 8, 100000000},
+		{"one satoshi", 0.00000001, 8, 1},
+		{"rounding error", 0.1 + 0.2, 8, 30000000},
+		{"large value", 20999999.9769, 8, 2099999997690000},
+		{"negative", -0.5, 8, -50000000},
+		{"two decimals", 1.23, 2, 123},
+		{"zero decimals", 42, 0, 42},
+		{"NaN", math.NaN(), 8, -1},
+		{"infinity", math.Inf(1), 8, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSmallestUnit(tt.value, tt.decimals); got != tt.want {
+				t.Errorf("ParseSmallestUnit(%v, %d) = %d, want %d",
+					tt.value, tt.decimals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTimestampIn(t *testing.T) {
+	const timestamp = "2021-03-14T15:09:26Z"
+
+	tests := []struct {
+		name      string
+		timestamp string
+		location  *time.Location
+		want      string
+	}{
+		{"UTC", timestamp, time.UTC, "2021-03-14T15:09:26Z"},
+		{"ahead of UTC", timestamp, time.FixedZone("JST", 9*60*60), "2021-03-15T00:09:26+09:00"},
+		{"behind UTC", timestamp, time.FixedZone("EST", -5*60*60), "2021-03-14T10:09:26-05:00"},
+		{"invalid timestamp", "yesterday", time.UTC, "yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTimestampIn(tt.timestamp, tt.location); got != tt.want {
+				t.Errorf("FormatTimestampIn() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}